@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/DonutLaser/git-client/filesystem"
 	"github.com/DonutLaser/git-client/font"
@@ -20,8 +23,20 @@ const (
 	MODE_NORMAL AppMode = iota
 	MODE_DELETE
 	MODE_STASH
+	MODE_LINES
+	MODE_HISTORY
+	MODE_COMMIT
+	MODE_BLAME
+	MODE_CONFLICT
+	MODE_REBASE
+	MODE_STASH_FILES
+	MODE_TAGS
+	MODE_BRANCHES
 )
 
+// Tags are listed next to branches in the checkout picker with this after their name
+const TAG_SUFFIX = " (tag)"
+
 type Repo struct {
 	Name          string
 	Path          string
@@ -29,6 +44,10 @@ type Repo struct {
 	Branches      []string
 	Changes       []git.GitStatusEntry
 	Stash         []git.GitStashEntry
+	Upstream      string
+	Ahead         int
+	Behind        int
+	Operation     git.GitOperationState
 }
 
 type App struct {
@@ -37,13 +56,30 @@ type App struct {
 	DiffView     DiffView
 	Search       QuickSearch
 	CommandInput CommandInput
+	CommitEditor CommitEditor
 	NoRepos      NoRepos
 	NoChanges    NoChanges
-
-	Mode     AppMode
-	Repo     Repo
-	Settings settings.Settings
-	RepoList []string
+	ErrorBanner  ErrorBanner
+	LogView      LogView
+	CommitFiles  Staging
+	BlameView    BlameView
+	ConflictView ConflictView
+	Rebase       RebasePlanner
+	Stashes      StashView
+	Tags         TagView
+	BranchList   BranchView
+	Task         BackgroundTask
+
+	Mode         AppMode
+	Repo         Repo
+	ActiveCommit git.GitCommit
+	ActiveStash  git.GitStashEntry
+	Settings     settings.Settings
+	LogReader    *git.GitLogReader
+	RepoList     []string
+
+	// Commits of the running interactive rebase that still wait for a new message, by original hash
+	PendingRewords map[string]git.GitCommit
 
 	Fonts map[string]font.Font
 	Icons map[string]image.Image
@@ -58,8 +94,20 @@ func NewApp(windowWidth int32, windowHeight int32, renderer *sdl.Renderer) (resu
 	result.DiffView = NewDiffView(windowWidth, windowHeight)
 	result.Search = NewQuickSearch(windowWidth, windowHeight)
 	result.CommandInput = NewCommandInput(windowWidth, windowHeight)
+	result.CommitEditor = NewCommitEditor(windowWidth, windowHeight)
 	result.NoRepos = NewNoRepos(windowWidth, windowHeight)
 	result.NoChanges = NewNoChanges(windowWidth, windowHeight)
+	result.ErrorBanner = NewErrorBanner(windowWidth, windowHeight)
+	result.LogView = NewLogView(windowWidth, windowHeight)
+	result.CommitFiles = NewStaging(windowHeight)
+	result.CommitFiles.UnstagedTitle = "Files"
+	result.BlameView = NewBlameView(windowWidth, windowHeight)
+	result.ConflictView = NewConflictView(windowWidth, windowHeight)
+	result.Rebase = NewRebasePlanner(windowWidth, windowHeight)
+	result.Stashes = NewStashView(windowWidth, windowHeight)
+	result.Tags = NewTagView(windowWidth, windowHeight)
+	result.BranchList = NewBranchView(windowWidth, windowHeight)
+	result.Task = NewBackgroundTask(windowWidth, windowHeight)
 
 	result.Mode = MODE_NORMAL
 
@@ -78,6 +126,9 @@ func NewApp(windowWidth int32, windowHeight int32, renderer *sdl.Renderer) (resu
 
 	result.Settings = settings.LoadSettings()
 
+	result.DiffView.Layout = result.Settings.DiffLayout
+	result.DiffView.UnifiedBelowWidth = int32(result.Settings.UnifiedDiffBelowWidth)
+
 	result.Quit = false
 
 	return
@@ -103,6 +154,12 @@ func (app *App) Close() {
 	icon.Unload()
 	icon = app.Icons["stash"]
 	icon.Unload()
+
+	if app.CommitEditor.Active {
+		app.CommitEditor.CloseCallback(app.CommitEditor.Message())
+	}
+
+	app.closeHistory()
 }
 
 func (app *App) Resize(windowWidth int32, windowHeight int32) {
@@ -111,8 +168,19 @@ func (app *App) Resize(windowWidth int32, windowHeight int32) {
 	app.DiffView.Resize(windowWidth, windowHeight)
 	app.Search.Resize(windowWidth, windowHeight)
 	app.CommandInput.Resize(windowWidth, windowHeight)
+	app.CommitEditor.Resize(windowWidth, windowHeight)
 	app.NoRepos.Resize(windowWidth, windowHeight)
 	app.NoChanges.Resize(windowWidth, windowHeight)
+	app.ErrorBanner.Resize(windowWidth, windowHeight)
+	app.LogView.Resize(windowWidth, windowHeight)
+	app.CommitFiles.Resize(windowHeight)
+	app.BlameView.Resize(windowWidth, windowHeight)
+	app.ConflictView.Resize(windowWidth, windowHeight)
+	app.Rebase.Resize(windowWidth, windowHeight)
+	app.Stashes.Resize(windowWidth, windowHeight)
+	app.Tags.Resize(windowWidth, windowHeight)
+	app.BranchList.Resize(windowWidth, windowHeight)
+	app.Task.Resize(windowWidth, windowHeight)
 }
 
 func (app *App) Refresh() {
@@ -121,14 +189,18 @@ func (app *App) Refresh() {
 			return
 		}
 
-		app.Repo.Branches = git.ListBranches(app.Repo.Path)
-		app.Repo.Changes = git.Status(app.Repo.Path)
+		branches, err := git.ListBranches(app.Repo.Path)
+		if app.reportError(err) {
+			return
+		}
 
-		app.Staging.ShowEntries(app.Repo.Changes)
+		app.Repo.Branches = branches
+		app.refreshChanges()
+		app.refreshRemoteStatus()
 
-		if len(app.Repo.Changes) > 0 {
-			activeEntry := app.Staging.GetActiveEntry()
-			app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
+		// The file may have been edited outside while resolving its conflicts
+		if app.Mode == MODE_CONFLICT {
+			app.reloadConflicts()
 		}
 
 		return
@@ -144,6 +216,12 @@ func (app *App) Refresh() {
 }
 
 func (app *App) Tick(input *Input) {
+	if app.Task.Active {
+		app.Task.Tick(input)
+
+		return
+	}
+
 	if app.Search.Active {
 		app.Search.Tick(input)
 
@@ -156,17 +234,38 @@ func (app *App) Tick(input *Input) {
 		return
 	}
 
+	if app.CommitEditor.Active {
+		app.handleCommitEditorInput(input)
+
+		return
+	}
+
 	if app.Mode == MODE_NORMAL {
 		app.handleNormalInput(input)
 	} else if app.Mode == MODE_DELETE {
 		app.handleDeleteInput(input)
 	} else if app.Mode == MODE_STASH {
 		app.handleStashInput(input)
+	} else if app.Mode == MODE_LINES {
+		app.handleLinesInput(input)
+	} else if app.Mode == MODE_HISTORY {
+		app.handleHistoryInput(input)
+	} else if app.Mode == MODE_COMMIT || app.Mode == MODE_STASH_FILES {
+		app.handleCommitInput(input)
+	} else if app.Mode == MODE_BLAME {
+		app.handleBlameInput(input)
+	} else if app.Mode == MODE_CONFLICT {
+		app.handleConflictInput(input)
+	} else if app.Mode == MODE_REBASE {
+		app.handleRebaseInput(input)
+	} else if app.Mode == MODE_TAGS {
+		app.handleTagsInput(input)
+	} else if app.Mode == MODE_BRANCHES {
+		app.handleBranchesInput(input)
 	} else {
 		panic("Unreachable")
 	}
 
-	// ctrl + alt + o to clone repo
 	// ctrl + shift + o to open repo folder
 	// ctrl + r to open pull request
 	// ctrl + shift + n to new branch
@@ -181,17 +280,41 @@ func (app *App) Render(renderer *sdl.Renderer) {
 	} else {
 		app.Statusbar.Render(renderer, app)
 
-		if len(app.Repo.Changes) > 0 {
+		if app.Mode == MODE_HISTORY {
+			app.LogView.Render(renderer, app)
+		} else if app.Mode == MODE_STASH {
+			app.Stashes.Render(renderer, app)
+		} else if app.Mode == MODE_TAGS {
+			app.Tags.Render(renderer, app)
+		} else if app.Mode == MODE_BRANCHES {
+			app.BranchList.Render(renderer, app)
+		} else if app.Mode == MODE_COMMIT || app.Mode == MODE_STASH_FILES {
+			app.CommitFiles.Render(renderer, app)
+			if len(app.CommitFiles.Entries) > 0 {
+				app.DiffView.Render(renderer, app)
+			}
+		} else if app.Mode == MODE_BLAME {
+			app.BlameView.Render(renderer, app)
+		} else if app.Mode == MODE_CONFLICT {
+			app.Staging.Render(renderer, app)
+			app.ConflictView.Render(renderer, app)
+		} else if app.Mode == MODE_REBASE {
+			app.Rebase.Render(renderer, app)
+		} else if len(app.Repo.Changes) > 0 {
 			app.Staging.Render(renderer, app)
 			app.DiffView.Render(renderer, app)
 		} else {
 			app.NoChanges.Render(renderer, app)
 		}
 
+		app.CommitEditor.Render(renderer, app)
 		app.Search.Render(renderer, app)
 		app.CommandInput.Render(renderer, app)
+		app.Task.Render(renderer, app)
 	}
 
+	app.ErrorBanner.Render(renderer, app)
+
 	renderer.Present()
 }
 
@@ -200,18 +323,155 @@ func (app *App) setMode(mode AppMode) {
 }
 
 func (app *App) handleNormalInput(input *Input) {
-	if input.TypedCharacter == 'j' {
+	if input.Escape {
+		app.ErrorBanner.Hide()
+	} else if input.TypedCharacter == 'j' {
 		if len(app.Repo.Changes) > 0 {
 			app.Staging.GoToNextEntry()
-			activeEntry := app.Staging.GetActiveEntry()
-
-			app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
+			app.showActiveEntryDiff()
 		}
 	} else if input.TypedCharacter == 'k' {
 		if len(app.Repo.Changes) > 0 {
 			app.Staging.GoToPrevEntry()
+			app.showActiveEntryDiff()
+		}
+	} else if input.TypedCharacter == 'l' {
+		if len(app.Staging.Entries) > 0 && app.DiffView.HasChunks() {
+			app.DiffView.EnterLineMode()
+			app.setMode(MODE_LINES)
+		}
+	} else if input.TypedCharacter == 'h' {
+		if input.Ctrl {
+			app.openHistory()
+		}
+	} else if input.TypedCharacter == 'f' {
+		repoPath := app.Repo.Path
+
+		app.Task.Start("Fetching", func(ctx context.Context, onProgress func(git.GitProgress)) error {
+			return git.Fetch(ctx, "", repoPath, onProgress)
+		}, func(err error) {
+			app.reportRemoteError(err)
+			app.refreshRemoteStatus()
+		})
+	} else if input.TypedCharacter == 'F' {
+		repoPath := app.Repo.Path
+
+		app.Task.Start("Pulling", func(ctx context.Context, onProgress func(git.GitProgress)) error {
+			return git.Pull(ctx, repoPath, onProgress)
+		}, func(err error) {
+			app.reportRemoteError(err)
+			app.setRepository(app.Repo.Path)
+		})
+	} else if input.TypedCharacter == 'P' {
+		options := git.GitPushOptions{SetUpstream: true, ForceWithLease: input.Ctrl}
+		repoPath := app.Repo.Path
+
+		app.Task.Start("Pushing", func(ctx context.Context, onProgress func(git.GitProgress)) error {
+			return git.Push(ctx, options, repoPath, onProgress)
+		}, func(err error) {
+			app.reportRemoteError(err)
+			app.refreshRemoteStatus()
+		})
+	} else if input.TypedCharacter == 'm' {
+		if app.Repo.Operation.Operation == git.GIT_OPERATION_NONE {
+			app.Search.Open("Branch to merge", app.otherBranches(), SEARCH_INCLUDES, func(branchName string) {
+				strategies := []string{"Fast-forward only", "No fast-forward", "Squash"}
+
+				app.Search.Open("Merge "+branchName, strategies, SEARCH_BEGINS_WITH, func(strategyName string) {
+					strategy := git.GIT_MERGE_FAST_FORWARD
+					if strategyName == strategies[1] {
+						strategy = git.GIT_MERGE_NO_FAST_FORWARD
+					} else if strategyName == strategies[2] {
+						strategy = git.GIT_MERGE_SQUASH
+					}
+
+					err := git.Merge(branchName, strategy, app.Repo.Path)
+					app.reportError(err)
+					app.setRepository(app.Repo.Path)
+				})
+			})
+		}
+	} else if input.TypedCharacter == 'r' {
+		if app.Repo.Operation.Operation == git.GIT_OPERATION_NONE {
+			app.Search.Open("Branch to rebase onto", app.otherBranches(), SEARCH_INCLUDES, func(branchName string) {
+				err := git.Rebase(branchName, app.Repo.Path)
+				app.reportError(err)
+				app.setRepository(app.Repo.Path)
+			})
+		}
+	} else if input.TypedCharacter == 'i' {
+		if app.Repo.Operation.Operation == git.GIT_OPERATION_NONE {
+			app.Search.Open("Base to rebase onto", app.otherBranches(), SEARCH_INCLUDES, func(branchName string) {
+				app.openRebasePlanner(branchName)
+			})
+		}
+	} else if input.TypedCharacter == 'Y' {
+		if app.Repo.Operation.Operation == git.GIT_OPERATION_NONE {
+			app.pickCommits("Commit to cherry-pick", func(revisions []string) (git.GitPickResult, error) {
+				return git.CherryPick(revisions, app.Repo.Path)
+			})
+		}
+	} else if input.TypedCharacter == 'R' {
+		if app.Repo.Operation.Operation == git.GIT_OPERATION_NONE {
+			app.pickCommits("Commit to revert", func(revisions []string) (git.GitPickResult, error) {
+				return git.Revert(revisions, app.Repo.Path)
+			})
+		}
+	} else if input.TypedCharacter == 'C' {
+		if app.Repo.Operation.Operation != git.GIT_OPERATION_NONE {
+			err := git.ContinueOperation(app.Repo.Operation.Operation, app.Repo.Path)
+			app.reportError(err)
+			app.setRepository(app.Repo.Path)
+			app.promptRebaseReword()
+		}
+	} else if input.TypedCharacter == 'A' {
+		if app.Repo.Operation.Operation != git.GIT_OPERATION_NONE {
+			err := git.AbortOperation(app.Repo.Operation.Operation, app.Repo.Path)
+			app.reportError(err)
+			app.setRepository(app.Repo.Path)
+		}
+	} else if input.TypedCharacter == 'X' {
+		if app.Repo.Operation.Operation == git.GIT_OPERATION_REBASE {
+			err := git.SkipOperation(app.Repo.Operation.Operation, app.Repo.Path)
+			app.reportError(err)
+			app.setRepository(app.Repo.Path)
+			app.promptRebaseReword()
+		}
+	} else if input.TypedCharacter == 'c' {
+		if len(app.Staging.Entries) > 0 && app.Staging.GetActiveEntry().Type == git.GIT_ENTRY_CONFLICTED {
+			conflicts, err := git.ReadConflictFile(app.Staging.GetActiveEntryFileName(), app.Repo.Path)
+			if !app.reportError(err) {
+				app.ConflictView.ShowConflicts(conflicts)
+				app.setMode(MODE_CONFLICT)
+			}
+		}
+	} else if input.TypedCharacter == 'b' {
+		if len(app.Staging.Entries) > 0 {
+			activeEntry := app.Staging.GetActiveEntry()
+
+			blame, err := git.Blame(activeEntry.Filename, "", app.Repo.Path)
+			if !app.reportError(err) {
+				app.BlameView.ShowBlame(blame)
+				app.setMode(MODE_BLAME)
+			}
+		}
+	} else if input.TypedCharacter == 'J' {
+		app.DiffView.GoToNextChunk()
+	} else if input.TypedCharacter == 'K' {
+		app.DiffView.GoToPrevChunk()
+	} else if input.TypedCharacter == 'S' {
+		if len(app.Staging.Entries) > 0 && app.DiffView.HasChunks() {
 			activeEntry := app.Staging.GetActiveEntry()
-			app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
+
+			var err error
+			if activeEntry.Staged {
+				err = git.UnstageHunk(activeEntry, app.DiffView.Data, app.DiffView.ActiveChunk, app.Repo.Path)
+			} else {
+				err = git.StageHunk(activeEntry, app.DiffView.Data, app.DiffView.ActiveChunk, app.Repo.Path)
+			}
+
+			app.reportError(err)
+			app.refreshChanges()
 		}
 	} else if input.TypedCharacter == 'L' {
 		app.DiffView.ScrollDown()
@@ -219,16 +479,43 @@ func (app *App) handleNormalInput(input *Input) {
 		app.DiffView.ScrollUp()
 	} else if input.TypedCharacter == 'v' {
 		if len(app.Repo.Changes) > 0 {
-			app.Staging.ToggleEntrySelected()
+			activeEntry := app.Staging.GetActiveEntry()
+
+			var err error
+			if activeEntry.Staged {
+				err = git.Unstage(activeEntry, app.Repo.Path)
+			} else {
+				err = git.Stage(activeEntry, app.Repo.Path)
+			}
+
+			app.reportError(err)
+			app.refreshChanges()
 		}
 	} else if input.TypedCharacter == 'V' {
 		if len(app.Repo.Changes) > 0 {
-			app.Staging.ToggleAllEntriesSelected()
+			var err error
+			if app.Staging.HasUnstagedEntries() {
+				err = git.StageAll(app.Repo.Path)
+			} else {
+				err = git.UnstageAll(app.Repo.Path)
+			}
+
+			app.reportError(err)
+			app.refreshChanges()
 		}
 	} else if input.TypedCharacter == 'd' {
 		app.setMode(MODE_DELETE)
 	} else if input.TypedCharacter == 's' {
+		app.refreshStash()
 		app.setMode(MODE_STASH)
+	} else if input.TypedCharacter == ' ' {
+		app.Staging.ToggleMark()
+	} else if input.TypedCharacter == 't' {
+		app.refreshTags()
+		app.setMode(MODE_TAGS)
+	} else if input.TypedCharacter == 'B' {
+		app.refreshBranchList()
+		app.setMode(MODE_BRANCHES)
 	} else if input.TypedCharacter == '`' {
 
 	} else if input.TypedCharacter == 'p' {
@@ -256,30 +543,40 @@ func (app *App) handleNormalInput(input *Input) {
 					app.Settings.Save()
 				})
 			} else {
-				app.Search.Open("Branch name", app.Repo.Branches, SEARCH_INCLUDES, func(branchName string) {
-					git.SwitchToBranch(branchName, app.Repo.Path)
-					app.Repo.CurrentBranch = branchName
-
-					app.Settings.SetActiveBranch(branchName)
-					app.Settings.Save()
-
-					app.Statusbar.ShowBranchName(app.Repo.CurrentBranch)
-					app.Repo.Changes = git.Status(app.Repo.Path)
-					app.Repo.Stash = git.ListStash(app.Repo.Path)
-
-					app.Statusbar.ShowStashExists(git.DoesBranchHaveStash(app.Repo.CurrentBranch, app.Repo.Stash))
+				tags, err := git.ListTags(app.Repo.Path)
+				app.reportError(err)
+
+				// Branches are shown with how far they are from their upstream, so the picked label is mapped back to its branch
+				branchesByLabel := make(map[string]git.GitBranch)
+				var targets []string
+				for _, branch := range app.listBranches() {
+					label := branchPickerLabel(branch)
+					branchesByLabel[label] = branch
+					targets = append(targets, label)
+				}
 
-					app.Staging.ShowEntries(app.Repo.Changes)
+				// Tags can be checked out too, they are told apart by a suffix that no ref name can contain
+				for _, tag := range tags {
+					targets = append(targets, tag.Name+TAG_SUFFIX)
+				}
 
-					if len(app.Repo.Changes) > 0 {
-						activeEntry := app.Staging.GetActiveEntry()
-						app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
+				app.Search.Open("Branch or tag name", targets, SEARCH_INCLUDES, func(target string) {
+					if branch, ok := branchesByLabel[target]; ok {
+						app.checkoutBranch(branch)
+					} else if strings.HasSuffix(target, TAG_SUFFIX) {
+						app.checkoutDetached(strings.TrimSuffix(target, TAG_SUFFIX))
 					}
 				})
 			}
 		}
 	} else if input.TypedCharacter == 'o' {
-		if input.Ctrl {
+		if input.Ctrl && input.Alt {
+			app.CommandInput.Open("Url or path of repository to clone", func(url string) {
+				app.CommandInput.Open("Path to clone into", func(folderPath string) {
+					app.cloneRepository(url, folderPath)
+				})
+			})
+		} else if input.Ctrl {
 			app.CommandInput.Open("Path to repository folder", func(folderPath string) {
 				app.setRepository(folderPath)
 				app.Settings.AddRepo(folderPath)
@@ -300,21 +597,44 @@ func (app *App) handleNormalInput(input *Input) {
 			settings.OpenSettingsInExternalProgram()
 		}
 	} else if input.TypedCharacter == 'I' {
-		app.CommandInput.Open("Commit message", func(message string) {
-			app.Repo.Changes = git.Commit(app.Repo.Changes, message, app.Repo.Path)
-			app.Staging.ShowEntries(app.Repo.Changes)
+		app.openCommitEditor()
+	} else if input.TypedCharacter == 'u' {
+		changes, err := git.UndoLastCommit(app.Repo.Path)
+		if app.reportError(err) {
+			return
+		}
 
-			if len(app.Repo.Changes) > 0 {
-				activeEntry := app.Staging.GetActiveEntry()
-				app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
-			}
+		app.showChanges(changes)
+	} else if input.TypedCharacter == 'e' {
+		app.confirmRewriteHead("amend", func() {
+			app.amendCommit("")
 		})
-	} else if input.TypedCharacter == 'u' {
-		app.Repo.Changes = git.UndoLastCommit(app.Repo.Path)
-		app.Staging.ShowEntries(app.Repo.Changes)
+	} else if input.TypedCharacter == 'E' || input.TypedCharacter == 'W' {
+		message, err := git.GetLastCommitMessage(app.Repo.Path)
+		if app.reportError(err) {
+			return
+		}
 
-		activeEntry := app.Staging.GetActiveEntry()
-		app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
+		// E amends with the staged entries, W only changes the message
+		amend := input.TypedCharacter == 'E'
+		action := "reword"
+		if amend {
+			action = "amend"
+		}
+
+		app.confirmRewriteHead(action, func() {
+			app.prepareCommitEditor()
+			app.CommitEditor.Open(message, func(newMessage string) {
+				if amend {
+					app.amendCommit(newMessage)
+					return
+				}
+
+				err := git.RewordCommit(newMessage, app.Repo.Path)
+				app.reportError(err)
+				app.refreshRemoteStatus()
+			}, func(string) {})
+		})
 	} else if input.TypedCharacter == 'n' {
 		if input.Ctrl {
 			app.CommandInput.Open("Path to new repository folder", func(folderPath string) {
@@ -325,7 +645,11 @@ func (app *App) handleNormalInput(input *Input) {
 					}
 				}
 
-				git.CreateRepository(folderPath)
+				err := git.CreateRepository(folderPath)
+				if app.reportError(err) {
+					return
+				}
+
 				app.setRepository(folderPath)
 				app.Settings.AddRepo(folderPath)
 				app.Settings.SetActiveRepo(folderPath)
@@ -336,20 +660,44 @@ func (app *App) handleNormalInput(input *Input) {
 	} else if input.TypedCharacter == 'N' {
 		if input.Ctrl {
 			app.CommandInput.Open("New branch name", func(branchName string) {
-				git.CreateBranch(branchName, app.Repo.Path)
-				app.Repo.CurrentBranch = git.GetCurrentBranch(app.Repo.Path)
-				app.Repo.Branches = git.ListBranches(app.Repo.Path)
-				app.Repo.Changes = git.Status(app.Repo.Path)
+				err := git.CreateBranch(branchName, app.Repo.Path)
+				if app.reportError(err) {
+					return
+				}
+
+				app.Repo.CurrentBranch, err = git.GetCurrentBranch(app.Repo.Path)
+				if app.reportError(err) {
+					return
+				}
+
+				app.Repo.Branches, err = git.ListBranches(app.Repo.Path)
+				if app.reportError(err) {
+					return
+				}
 
 				app.Statusbar.ShowRepoName(app.Repo.Name)
-				app.Statusbar.ShowBranchName(app.Repo.CurrentBranch)
+				app.showCurrentBranch()
 
-				app.Staging.ShowEntries(app.Repo.Changes)
+				app.refreshChanges()
+				app.refreshRemoteStatus()
 
 				app.Settings.SetActiveBranch(app.Repo.CurrentBranch)
 				app.Settings.Save()
 			})
 		}
+	} else if input.TypedCharacter == 'g' {
+		app.DiffView.ToggleGranularity()
+	} else if input.TypedCharacter == 'U' {
+		app.toggleDiffLayout()
+	} else if input.TypedCharacter == '[' {
+		app.DiffView.ExpandContextAbove()
+	} else if input.TypedCharacter == ']' {
+		app.DiffView.ExpandContextBelow()
+	} else if input.TypedCharacter == '=' {
+		app.DiffView.ToggleWholeFile()
+		app.reloadDiff()
+	} else if input.TypedCharacter == '.' {
+		app.openDiffOptions()
 	} else if input.TypedCharacter == 'w' {
 		if input.Ctrl {
 			app.Quit = true
@@ -366,28 +714,40 @@ func (app *App) handleDeleteInput(input *Input) {
 	if input.TypedCharacter == 'd' {
 		if len(app.Repo.Changes) > 0 {
 			activeEntry := app.Staging.GetActiveEntry()
-			if activeEntry.Type == git.GIT_ENTRY_NEW {
-				// `git restore`` doesn't work on new files so we manually delete them
+			if activeEntry.Type == git.GIT_ENTRY_NEW_UNSTAGED {
+				// `git restore`` doesn't work on untracked files so we manually delete them
 				// because that's what `git restore` would do anyway
 				os.Remove(fmt.Sprintf("%s/%s", app.Repo.Path, activeEntry.Filename))
 			} else {
-				git.Discard(activeEntry.Filename, app.Repo.Path)
+				app.reportError(git.Discard(activeEntry, app.Repo.Path))
 			}
 
-			app.Repo.Changes = git.Status(app.Repo.Path)
-			app.Staging.ShowEntries(app.Repo.Changes)
+			app.refreshChanges()
+		}
+
+		app.setMode(MODE_NORMAL)
+	} else if input.TypedCharacter == 'h' {
+		if len(app.Staging.Entries) > 0 && app.DiffView.HasChunks() {
+			activeEntry := app.Staging.GetActiveEntry()
 
-			if len(app.Repo.Changes) > 0 {
-				activeEntry = app.Staging.GetActiveEntry()
-				app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
+			if activeEntry.Type == git.GIT_ENTRY_NEW_UNSTAGED {
+				os.Remove(fmt.Sprintf("%s/%s", app.Repo.Path, activeEntry.Filename))
+			} else {
+				app.reportError(git.DiscardHunk(activeEntry, app.DiffView.Data, app.DiffView.ActiveChunk, app.Repo.Path))
 			}
+
+			app.refreshChanges()
 		}
 
 		app.setMode(MODE_NORMAL)
 	} else if input.TypedCharacter == 'a' {
-		git.DiscardAll(app.Repo.Path)
+		err := git.DiscardAll(app.Repo.Path)
+		if app.reportError(err) {
+			app.refreshChanges()
+		} else {
+			app.Staging.ShowEntries([]git.GitStatusEntry{})
+		}
 
-		app.Staging.ShowEntries([]git.GitStatusEntry{})
 		app.setMode(MODE_NORMAL)
 	}
 }
@@ -398,61 +758,1151 @@ func (app *App) handleStashInput(input *Input) {
 		return
 	}
 
-	if input.TypedCharacter == 's' {
-		git.Stash(app.Repo.Path)
+	if input.TypedCharacter == 'j' {
+		app.Stashes.GoToNextEntry()
+	} else if input.TypedCharacter == 'k' {
+		app.Stashes.GoToPrevEntry()
+	} else if input.TypedCharacter == 's' {
+		err := git.Stash(app.Repo.Path)
+		if !app.reportError(err) {
+			app.refreshChanges()
+			app.refreshStash()
+		}
+	} else if input.TypedCharacter == 'S' {
+		filenames := app.Staging.GetMarkedFileNames()
+		if len(filenames) > 0 {
+			app.CommandInput.Open(fmt.Sprintf("Message for stash of %d files", len(filenames)), func(message string) {
+				err := git.StashSelectedFiles(filenames, message, app.Repo.Path)
+				if !app.reportError(err) {
+					app.Staging.ClearMarks()
+					app.refreshChanges()
+					app.refreshStash()
+				}
+			})
+		}
+	} else if app.Stashes.HasEntries() {
+		app.handleStashEntryInput(input, app.Stashes.GetActiveEntry())
+	}
+}
 
-		app.Repo.Changes = git.Status(app.Repo.Path)
-		app.Repo.Stash = git.ListStash(app.Repo.Path)
-		app.Staging.ShowEntries(app.Repo.Changes)
-		app.Statusbar.ShowStashExists(git.DoesBranchHaveStash(app.Repo.CurrentBranch, app.Repo.Stash))
+// Actions on an existing stash
+func (app *App) handleStashEntryInput(input *Input, entry git.GitStashEntry) {
+	if input.TypedCharacter == 'l' || input.TypedCharacter == '\n' {
+		files, err := git.StashFiles(entry, app.Repo.Path)
+		if app.reportError(err) {
+			return
+		}
 
-		app.setMode(MODE_NORMAL)
-	} else if input.TypedCharacter == 'a' {
-		index := git.GetStashIndex(app.Repo.CurrentBranch, app.Repo.Stash)
+		app.ActiveStash = entry
+		app.setMode(MODE_STASH_FILES)
 
-		if index != "" {
-			app.Repo.Changes = git.ApplyStash(index, app.Repo.Path)
-			app.Repo.Stash = git.ListStash(app.Repo.Path)
-			app.Staging.ShowEntries(app.Repo.Changes)
-			app.Statusbar.ShowStashExists(false)
+		app.CommitFiles.ShowEntries(files)
+		app.CommitFiles.ResetActiveEntry()
+		if len(app.CommitFiles.Entries) > 0 {
+			app.showActiveCommitFileDiff()
+		}
+	} else if input.TypedCharacter == 'a' || input.TypedCharacter == 'p' {
+		var changes []git.GitStatusEntry
+		var err error
+		if input.TypedCharacter == 'a' {
+			changes, err = git.ApplyStash(entry.Index, app.Repo.Path)
+		} else {
+			changes, err = git.PopStash(entry.Index, app.Repo.Path)
+		}
 
-			if len(app.Repo.Changes) > 0 {
-				activeEntry := app.Staging.GetActiveEntry()
-				app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
-			}
+		if app.reportError(err) {
+			// A conflicting apply still changes the working tree, so show what it left behind
+			app.refreshChanges()
+		} else {
+			app.showChanges(changes)
 		}
 
+		app.refreshStash()
 		app.setMode(MODE_NORMAL)
 	} else if input.TypedCharacter == 'd' {
-		index := git.GetStashIndex(app.Repo.CurrentBranch, app.Repo.Stash)
+		err := git.DeleteStash(entry.Index, app.Repo.Path)
+		if !app.reportError(err) {
+			app.refreshStash()
+		}
+	} else if input.TypedCharacter == 'r' {
+		app.CommandInput.OpenWithValue("New stash message", entry.Message, func(message string) {
+			err := git.RenameStash(entry, message, app.Repo.Path)
+			app.reportError(err)
+			app.refreshStash()
+		})
+	} else if input.TypedCharacter == 'b' {
+		app.CommandInput.Open("Branch name for "+entry.Index, func(branchName string) {
+			err := git.BranchFromStash(entry.Index, branchName, app.Repo.Path)
+			if app.reportError(err) {
+				return
+			}
+
+			app.Settings.SetActiveBranch(branchName)
+			app.Settings.Save()
+
+			app.setMode(MODE_NORMAL)
+			app.setRepository(app.Repo.Path)
+		})
+	}
+}
+
+func (app *App) handleLinesInput(input *Input) {
+	if input.Escape && app.DiffView.Selecting {
+		app.DiffView.ToggleSelection()
+		return
+	}
+
+	if input.Escape || input.TypedCharacter == 'h' {
+		app.DiffView.ExitLineMode()
+		app.setMode(MODE_NORMAL)
+		return
+	}
+
+	if input.TypedCharacter == 'j' {
+		app.DiffView.GoToNextLine()
+	} else if input.TypedCharacter == 'k' {
+		app.DiffView.GoToPrevLine()
+	} else if input.TypedCharacter == 'J' {
+		app.DiffView.GoToNextChunk()
+	} else if input.TypedCharacter == 'K' {
+		app.DiffView.GoToPrevChunk()
+	} else if input.TypedCharacter == 'v' {
+		app.DiffView.ToggleSelection()
+	} else if input.TypedCharacter == 's' || input.TypedCharacter == 'x' {
+		lines := app.DiffView.GetSelectedLines()
+		if len(lines) == 0 {
+			return
+		}
 
-		if index != "" {
-			git.DeleteStash(index, app.Repo.Path)
-			app.Statusbar.ShowStashExists(false)
+		activeEntry := app.Staging.GetActiveEntry()
+
+		var err error
+		if input.TypedCharacter == 'x' {
+			err = git.DiscardLines(activeEntry, app.DiffView.Data, app.DiffView.ActiveChunk, lines, app.Repo.Path)
+		} else if activeEntry.Staged {
+			err = git.UnstageLines(activeEntry, app.DiffView.Data, app.DiffView.ActiveChunk, lines, app.Repo.Path)
+		} else {
+			err = git.StageLines(activeEntry, app.DiffView.Data, app.DiffView.ActiveChunk, lines, app.Repo.Path)
+		}
 
-			app.Repo.Stash = git.ListStash(app.Repo.Path)
+		app.reportError(err)
+		app.refreshChanges()
+
+		if len(app.Staging.Entries) == 0 || !app.DiffView.HasChunks() {
+			app.DiffView.ExitLineMode()
+			app.setMode(MODE_NORMAL)
 		}
+	}
+}
 
+func (app *App) handleHistoryInput(input *Input) {
+	if input.Escape || (input.Ctrl && input.TypedCharacter == 'h') {
 		app.setMode(MODE_NORMAL)
+		app.refreshChanges()
+		return
+	}
+
+	if input.TypedCharacter == 'j' {
+		app.LogView.GoToNextCommit(1)
+	} else if input.TypedCharacter == 'k' {
+		app.LogView.GoToPrevCommit(1)
+	} else if input.TypedCharacter == 'J' {
+		app.LogView.GoToNextCommit(20)
+	} else if input.TypedCharacter == 'K' {
+		app.LogView.GoToPrevCommit(20)
+	} else if input.TypedCharacter == 'l' || input.TypedCharacter == '\n' {
+		if app.LogView.HasCommits() {
+			app.showCommit(app.LogView.GetActiveCommit())
+		}
+	} else if input.TypedCharacter == 'i' {
+		if app.LogView.HasCommits() && app.Repo.Operation.Operation == git.GIT_OPERATION_NONE {
+			app.openRebasePlanner(app.LogView.GetActiveCommit().Hash)
+		}
+	} else if input.TypedCharacter == 't' {
+		if app.LogView.HasCommits() {
+			app.createTag(app.LogView.GetActiveCommit())
+		}
+	} else if input.TypedCharacter == 'R' {
+		if app.LogView.HasCommits() && app.Repo.Operation.Operation == git.GIT_OPERATION_NONE {
+			result, err := git.Revert([]string{app.LogView.GetActiveCommit().Hash}, app.Repo.Path)
+			app.reportPickResult(result, err)
+
+			app.setMode(MODE_NORMAL)
+			app.setRepository(app.Repo.Path)
+		}
+	}
+
+	if app.LogView.NeedsMoreCommits() {
+		app.loadMoreHistory()
 	}
 }
 
-func (app *App) setRepository(repoPath string) {
-	app.Repo.Name = filepath.Base(repoPath)
-	app.Repo.Path = repoPath
-	app.Repo.CurrentBranch = git.GetCurrentBranch(app.Repo.Path)
-	app.Repo.Branches = git.ListBranches(app.Repo.Path)
-	app.Repo.Changes = git.Status(app.Repo.Path)
-	app.Repo.Stash = git.ListStash(app.Repo.Path)
+func (app *App) handleCommitInput(input *Input) {
+	if input.Escape || input.TypedCharacter == 'h' {
+		if app.Mode == MODE_STASH_FILES {
+			app.setMode(MODE_STASH)
+		} else {
+			app.setMode(MODE_HISTORY)
+		}
 
-	app.Statusbar.ShowRepoName(app.Repo.Name)
-	app.Statusbar.ShowBranchName(app.Repo.CurrentBranch)
-	app.Statusbar.ShowStashExists(git.DoesBranchHaveStash(app.Repo.CurrentBranch, app.Repo.Stash))
+		return
+	}
 
-	app.Staging.ShowEntries(app.Repo.Changes)
+	if input.TypedCharacter == 'j' {
+		if len(app.CommitFiles.Entries) > 0 {
+			app.CommitFiles.GoToNextEntry()
+			app.showActiveCommitFileDiff()
+		}
+	} else if input.TypedCharacter == 'k' {
+		if len(app.CommitFiles.Entries) > 0 {
+			app.CommitFiles.GoToPrevEntry()
+			app.showActiveCommitFileDiff()
+		}
+	} else if input.TypedCharacter == 'J' {
+		app.DiffView.GoToNextChunk()
+	} else if input.TypedCharacter == 'K' {
+		app.DiffView.GoToPrevChunk()
+	} else if input.TypedCharacter == 'L' {
+		app.DiffView.ScrollDown()
+	} else if input.TypedCharacter == 'H' {
+		app.DiffView.ScrollUp()
+	} else if input.TypedCharacter == 'g' {
+		app.DiffView.ToggleGranularity()
+	} else if input.TypedCharacter == 'U' {
+		app.toggleDiffLayout()
+	} else if input.TypedCharacter == '[' {
+		app.DiffView.ExpandContextAbove()
+	} else if input.TypedCharacter == ']' {
+		app.DiffView.ExpandContextBelow()
+	} else if input.TypedCharacter == '=' {
+		app.DiffView.ToggleWholeFile()
+		app.reloadDiff()
+	} else if input.TypedCharacter == '.' {
+		app.openDiffOptions()
+	}
+}
 
-	if len(app.Repo.Changes) > 0 {
-		activeEntry := app.Staging.GetActiveEntry()
-		app.DiffView.ShowDiff(git.DiffEntry(activeEntry, app.Repo.Path), activeEntry)
+// Diffs the shown file again, e.g. after the options it is diffed with changed
+func (app *App) reloadDiff() {
+	if app.Mode == MODE_COMMIT || app.Mode == MODE_STASH_FILES {
+		if len(app.CommitFiles.Entries) > 0 {
+			app.showActiveCommitFileDiff()
+		}
+	} else if len(app.Staging.Entries) > 0 {
+		app.showActiveEntryDiff()
+	}
+}
+
+// Lists the whitespace options of the repository, picking one turns it on or off
+func (app *App) openDiffOptions() {
+	options := app.Settings.GetDiffOptions(app.Repo.Path)
+
+	toggles := []struct {
+		name  string
+		value *bool
+	}{
+		{"Ignore whitespace changes", &options.IgnoreSpaceChange},
+		{"Ignore all whitespace", &options.IgnoreAllSpace},
+		{"Ignore blank lines", &options.IgnoreBlankLines},
+		{"Ignore CR at end of line", &options.IgnoreCRAtEOL},
+		{"Show whitespace", &options.ShowWhitespace},
+	}
+
+	labels := make([]string, 0, len(toggles))
+	values := make(map[string]*bool)
+	for _, toggle := range toggles {
+		label := "[ ] " + toggle.name
+		if *toggle.value {
+			label = "[x] " + toggle.name
+		}
+
+		labels = append(labels, label)
+		values[label] = toggle.value
 	}
+
+	app.Search.Open("Diff option", labels, SEARCH_INCLUDES, func(label string) {
+		value, ok := values[label]
+		if !ok {
+			return
+		}
+
+		*value = !*value
+		app.Settings.SetDiffOptions(app.Repo.Path, options)
+		app.Settings.Save()
+
+		app.DiffView.ShowWhitespace = options.ShowWhitespace
+		app.reloadDiff()
+	})
+}
+
+func (app *App) toggleDiffLayout() {
+	app.DiffView.ToggleLayout()
+
+	app.Settings.DiffLayout = app.DiffView.Layout
+	app.Settings.Save()
+}
+
+func (app *App) handleBlameInput(input *Input) {
+	if input.Escape {
+		app.setMode(MODE_NORMAL)
+		return
+	}
+
+	if input.Backspace {
+		app.BlameView.PopBlame()
+		return
+	}
+
+	if input.TypedCharacter == 'j' {
+		app.BlameView.GoToNextLine()
+	} else if input.TypedCharacter == 'k' {
+		app.BlameView.GoToPrevLine()
+	} else if input.TypedCharacter == 'L' {
+		app.BlameView.ScrollDown()
+	} else if input.TypedCharacter == 'H' {
+		app.BlameView.ScrollUp()
+	} else if input.TypedCharacter == 'p' {
+		if !app.BlameView.HasLines() {
+			return
+		}
+
+		commit := app.BlameView.GetActiveCommit()
+		if git.IsUncommittedHash(commit.Hash) {
+			app.reportError(errors.New("the line is not committed yet"))
+			return
+		}
+		if commit.PreviousHash == "" {
+			app.reportError(fmt.Errorf("%s has no parent that contains this file", commit.Hash[:7]))
+			return
+		}
+
+		blame, err := git.Blame(commit.PreviousFilename, commit.PreviousHash, app.Repo.Path)
+		if !app.reportError(err) {
+			app.BlameView.PushBlame(blame)
+		}
+	} else if input.TypedCharacter == 'g' {
+		if !app.BlameView.HasLines() {
+			return
+		}
+
+		commit := app.BlameView.GetActiveCommit()
+		if git.IsUncommittedHash(commit.Hash) {
+			app.reportError(errors.New("the line is not committed yet"))
+			return
+		}
+
+		if !git.IsAncestor(commit.Hash, "HEAD", app.Repo.Path) {
+			app.reportError(fmt.Errorf("%s is not reachable from the current branch", commit.Hash[:7]))
+			return
+		}
+
+		// Loading everything up to the commit at once is a single read instead of a page at a time
+		before, err := git.CountCommitsBefore(commit.Hash, app.Repo.Path)
+		if app.reportError(err) {
+			return
+		}
+
+		app.openHistory()
+		app.loadHistory(before + 1 - len(app.LogView.Commits))
+
+		if !app.LogView.SelectCommit(commit.Hash) {
+			app.reportError(fmt.Errorf("%s was not found in the history", commit.Hash[:7]))
+		}
+	}
+}
+
+func (app *App) handleConflictInput(input *Input) {
+	if input.Escape || input.TypedCharacter == 'h' {
+		app.setMode(MODE_NORMAL)
+	} else if input.TypedCharacter == 'j' {
+		app.ConflictView.GoToNextConflict()
+	} else if input.TypedCharacter == 'k' {
+		app.ConflictView.GoToPrevConflict()
+	} else if input.TypedCharacter == 'L' {
+		app.ConflictView.ScrollDown()
+	} else if input.TypedCharacter == 'H' {
+		app.ConflictView.ScrollUp()
+	} else if input.TypedCharacter == 'o' {
+		app.ConflictView.Resolve(git.GIT_RESOLUTION_OURS)
+	} else if input.TypedCharacter == 't' {
+		app.ConflictView.Resolve(git.GIT_RESOLUTION_THEIRS)
+	} else if input.TypedCharacter == 'b' {
+		app.ConflictView.Resolve(git.GIT_RESOLUTION_BOTH)
+	} else if input.TypedCharacter == 'x' {
+		app.ConflictView.Resolve(git.GIT_RESOLUTION_NONE)
+	} else if input.TypedCharacter == 'e' {
+		// Conflicts picked so far are written out, the rest keep their markers for editing by hand.
+		// The file is read back in when the window gets focus again.
+		err := git.WriteConflictFile(app.ConflictView.Data, app.Repo.Path)
+		if app.reportError(err) {
+			return
+		}
+
+		open.Start(filepath.Join(app.Repo.Path, app.ConflictView.Data.Filename))
+	} else if input.TypedCharacter == 'a' {
+		unresolved := app.ConflictView.Data.UnresolvedCount()
+		if unresolved > 0 {
+			app.ErrorBanner.Show(fmt.Errorf("%d conflicts in %s are not resolved yet", unresolved, app.ConflictView.Data.Filename))
+			return
+		}
+
+		if len(app.ConflictView.Data.Sections) > 0 {
+			err := git.WriteConflictFile(app.ConflictView.Data, app.Repo.Path)
+			if app.reportError(err) {
+				return
+			}
+		}
+
+		err := git.Stage(app.Staging.GetActiveEntry(), app.Repo.Path)
+		app.reportError(err)
+
+		app.refreshChanges()
+		app.setMode(MODE_NORMAL)
+	}
+}
+
+func (app *App) handleRebaseInput(input *Input) {
+	if input.Escape {
+		app.setMode(MODE_NORMAL)
+	} else if input.TypedCharacter == 'j' {
+		app.Rebase.GoToNextStep()
+	} else if input.TypedCharacter == 'k' {
+		app.Rebase.GoToPrevStep()
+	} else if input.TypedCharacter == 'J' {
+		app.Rebase.MoveStepDown()
+	} else if input.TypedCharacter == 'K' {
+		app.Rebase.MoveStepUp()
+	} else if input.TypedCharacter == 'p' {
+		app.Rebase.SetAction(git.GIT_REBASE_PICK)
+	} else if input.TypedCharacter == 'r' {
+		app.Rebase.SetAction(git.GIT_REBASE_REWORD)
+	} else if input.TypedCharacter == 'e' {
+		app.Rebase.SetAction(git.GIT_REBASE_EDIT)
+	} else if input.TypedCharacter == 's' {
+		app.Rebase.SetAction(git.GIT_REBASE_SQUASH)
+	} else if input.TypedCharacter == 'f' {
+		app.Rebase.SetAction(git.GIT_REBASE_FIXUP)
+	} else if input.TypedCharacter == 'd' {
+		app.Rebase.SetAction(git.GIT_REBASE_DROP)
+	} else if input.TypedCharacter == '\n' {
+		app.PendingRewords = make(map[string]git.GitCommit)
+		for _, step := range app.Rebase.Steps {
+			if step.Action == git.GIT_REBASE_REWORD {
+				app.PendingRewords[step.Commit.Hash] = step.Commit
+			}
+		}
+
+		err := git.InteractiveRebase(app.Rebase.Base, app.Rebase.Steps, app.Repo.Path)
+		app.reportError(err)
+
+		app.setMode(MODE_NORMAL)
+		app.setRepository(app.Repo.Path)
+		app.promptRebaseReword()
+	}
+}
+
+// Lets the user pick a commit from any branch and optionally the end of a range starting at it,
+// then runs pick with the chosen revisions
+func (app *App) pickCommits(title string, pick func(revisions []string) (git.GitPickResult, error)) {
+	commits, err := git.LogAllBranches(LOG_PAGE_SIZE, app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	items := make([]string, len(commits))
+	for index, commit := range commits {
+		items[index] = fmt.Sprintf("%s %s", commit.ShortHash, commit.Subject)
+	}
+
+	app.Search.Open(title, items, SEARCH_INCLUDES, func(first string) {
+		singleItem := "Only this commit"
+		rangeItems := append([]string{singleItem}, items...)
+
+		app.Search.Open("Up to and including", rangeItems, SEARCH_INCLUDES, func(last string) {
+			firstHash := strings.Fields(first)[0]
+
+			revisions := []string{firstHash}
+			if last != singleItem {
+				var err error
+				revisions, err = git.PickRange(firstHash, strings.Fields(last)[0], app.Repo.Path)
+				if app.reportError(err) {
+					return
+				}
+			}
+
+			result, err := pick(revisions)
+			app.reportPickResult(result, err)
+			app.setRepository(app.Repo.Path)
+		})
+	})
+}
+
+// A conflict is expected to happen and gets an explanation of how to go on instead of the raw git error
+func (app *App) reportPickResult(result git.GitPickResult, err error) {
+	if result.Conflicted {
+		app.ErrorBanner.Show(fmt.Errorf("stopped at %s after %d new commits, resolve the conflicts and continue with C or abort with A: %w", result.StoppedAt, result.NewCommits, git.ErrMergeConflict))
+		return
+	}
+
+	app.reportError(err)
+}
+
+func (app *App) openRebasePlanner(base string) {
+	commits, err := git.ListRebaseCommits(base, app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	if len(commits) == 0 {
+		app.ErrorBanner.Show(fmt.Errorf("there are no commits between %s and HEAD to rebase", base))
+		return
+	}
+
+	app.Rebase.ShowCommits(base, commits)
+	app.setMode(MODE_REBASE)
+}
+
+// When an interactive rebase stops right after a commit that was marked for rewording, asks for
+// its new subject and then lets the rebase go on
+func (app *App) promptRebaseReword() {
+	state := app.Repo.Operation
+	if state.Operation != git.GIT_OPERATION_REBASE || !state.StoppedAtBreak {
+		return
+	}
+
+	commit, ok := app.PendingRewords[state.LastRewritten]
+	if !ok {
+		return
+	}
+
+	delete(app.PendingRewords, state.LastRewritten)
+
+	app.CommandInput.OpenWithValue("New message for "+commit.ShortHash, commit.Subject, func(subject string) {
+		err := git.AmendCommitSubject(subject, app.Repo.Path)
+		if app.reportError(err) {
+			return
+		}
+
+		err = git.ContinueOperation(git.GIT_OPERATION_REBASE, app.Repo.Path)
+		app.reportError(err)
+		app.setRepository(app.Repo.Path)
+		app.promptRebaseReword()
+	})
+}
+
+func (app *App) handleTagsInput(input *Input) {
+	if input.Escape {
+		app.setMode(MODE_NORMAL)
+		return
+	}
+
+	repoPath := app.Repo.Path
+
+	if input.TypedCharacter == 'j' {
+		app.Tags.GoToNextTag()
+	} else if input.TypedCharacter == 'k' {
+		app.Tags.GoToPrevTag()
+	} else if input.TypedCharacter == 'n' {
+		app.createTag(git.GitCommit{})
+	} else if input.TypedCharacter == 'P' {
+		app.chooseRemote(func(remote string) {
+			app.Task.Start("Pushing tags", func(ctx context.Context, onProgress func(git.GitProgress)) error {
+				return git.PushAllTags(ctx, remote, repoPath, onProgress)
+			}, func(err error) {
+				app.reportRemoteError(err)
+			})
+		})
+	} else if app.Tags.HasTags() {
+		tag := app.Tags.GetActiveTag()
+
+		if input.TypedCharacter == 'l' || input.TypedCharacter == '\n' {
+			app.checkoutDetached(tag.Name)
+			app.setMode(MODE_NORMAL)
+		} else if input.TypedCharacter == 'd' {
+			err := git.DeleteTag(tag.Name, app.Repo.Path)
+			app.reportError(err)
+			app.refreshTags()
+		} else if input.TypedCharacter == 'D' {
+			app.chooseRemote(func(remote string) {
+				app.Task.Start("Deleting "+tag.Name+" from "+remote, func(ctx context.Context, onProgress func(git.GitProgress)) error {
+					return git.DeleteRemoteTag(ctx, remote, tag.Name, repoPath, onProgress)
+				}, func(err error) {
+					app.reportRemoteError(err)
+				})
+			})
+		} else if input.TypedCharacter == 'p' {
+			app.chooseRemote(func(remote string) {
+				app.Task.Start("Pushing "+tag.Name, func(ctx context.Context, onProgress func(git.GitProgress)) error {
+					return git.PushTag(ctx, remote, tag.Name, repoPath, onProgress)
+				}, func(err error) {
+					app.reportRemoteError(err)
+				})
+			})
+		}
+	}
+}
+
+func (app *App) handleBranchesInput(input *Input) {
+	if input.Escape {
+		app.setMode(MODE_NORMAL)
+		return
+	}
+
+	if input.TypedCharacter == 'j' {
+		app.BranchList.GoToNextBranch()
+	} else if input.TypedCharacter == 'k' {
+		app.BranchList.GoToPrevBranch()
+	} else if input.TypedCharacter == 's' {
+		app.Settings.BranchSortByDate = !app.Settings.BranchSortByDate
+		app.Settings.Save()
+
+		app.refreshBranchList()
+	} else if app.BranchList.HasBranches() {
+		branch := app.BranchList.GetActiveBranch()
+
+		if input.TypedCharacter == 'l' || input.TypedCharacter == '\n' {
+			app.checkoutBranch(branch)
+			app.refreshBranchList()
+		} else if branch.Remote {
+			return
+		} else if input.TypedCharacter == 'r' {
+			app.CommandInput.OpenWithValue("New name of "+branch.Name, branch.Name, func(newName string) {
+				err := git.RenameBranch(branch.Name, newName, app.Repo.Path)
+				if app.reportError(err) {
+					return
+				}
+
+				if branch.Current {
+					app.Repo.CurrentBranch = newName
+
+					app.Settings.SetActiveBranch(newName)
+					app.Settings.Save()
+
+					app.showCurrentBranch()
+				}
+
+				app.refreshBranchList()
+			})
+		} else if input.TypedCharacter == 'd' {
+			err := git.DeleteBranch(branch.Name, false, app.Repo.Path)
+			if errors.Is(err, git.ErrBranchNotMerged) {
+				app.ErrorBanner.Show(fmt.Errorf("%w. Press D to delete it anyway", err))
+			} else {
+				app.reportError(err)
+			}
+
+			app.refreshBranchList()
+		} else if input.TypedCharacter == 'D' {
+			if git.IsBranchMerged(branch.Name, app.Repo.Path) {
+				app.deleteBranch(branch.Name)
+				return
+			}
+
+			// Commits that exist only on this branch are lost, so make sure it was not pressed by accident
+			app.CommandInput.Open("Type "+branch.Name+" to delete it with its unmerged commits", func(name string) {
+				if name != branch.Name {
+					app.ErrorBanner.Show(fmt.Errorf("%s was not deleted, the name did not match", branch.Name))
+					return
+				}
+
+				app.deleteBranch(branch.Name)
+			})
+		} else if input.TypedCharacter == 'u' {
+			var remoteBranches []string
+			for _, other := range app.BranchList.Branches {
+				if other.Remote {
+					remoteBranches = append(remoteBranches, other.Name)
+				}
+			}
+
+			app.Search.Open("Upstream of "+branch.Name, remoteBranches, SEARCH_INCLUDES, func(upstream string) {
+				err := git.SetUpstream(branch.Name, upstream, app.Repo.Path)
+				app.reportError(err)
+
+				app.refreshBranchList()
+				app.refreshRemoteStatus()
+			})
+		}
+	}
+}
+
+func (app *App) deleteBranch(branchName string) {
+	err := git.DeleteBranch(branchName, true, app.Repo.Path)
+	app.reportError(err)
+
+	app.refreshBranchList()
+}
+
+// Also updates the branch names used elsewhere, e.g. by the merge and rebase pickers
+func (app *App) refreshBranchList() {
+	var err error
+	app.Repo.Branches, err = git.ListBranches(app.Repo.Path)
+	app.reportError(err)
+
+	app.BranchList.ShowBranches(app.listBranches())
+}
+
+// Local and remote-tracking branches in the order picked in the settings
+func (app *App) listBranches() (result []git.GitBranch) {
+	result, err := git.ListBranchDetails(true, app.Repo.Path)
+	app.reportError(err)
+
+	git.SortBranches(result, app.Settings.BranchSortByDate)
+
+	return
+}
+
+// A remote branch is checked out as a local branch that tracks it, reusing one that already exists
+func (app *App) checkoutBranch(branch git.GitBranch) {
+	name := branch.Name
+
+	var err error
+	if branch.Remote {
+		name = git.LocalBranchName(branch.Name)
+		if containsString(app.Repo.Branches, name) {
+			err = git.SwitchToBranch(name, app.Repo.Path)
+		} else {
+			err = git.CheckoutRemoteBranch(branch.Name, app.Repo.Path)
+		}
+	} else {
+		err = git.SwitchToBranch(name, app.Repo.Path)
+	}
+
+	if app.reportError(err) {
+		return
+	}
+
+	app.Repo.CurrentBranch = name
+	app.Repo.Branches, err = git.ListBranches(app.Repo.Path)
+	app.reportError(err)
+
+	app.Settings.SetActiveBranch(name)
+	app.Settings.Save()
+
+	app.showCurrentBranch()
+	app.refreshStash()
+	app.refreshChanges()
+	app.refreshRemoteStatus()
+}
+
+// e.g. "feature [ahead 1, behind 2]" or "origin/feature (remote)"
+func branchPickerLabel(branch git.GitBranch) string {
+	if branch.Remote {
+		return branch.Name + " (remote)"
+	} else if branch.UpstreamGone {
+		return branch.Name + " [gone]"
+	} else if branch.Ahead > 0 || branch.Behind > 0 {
+		return fmt.Sprintf("%s [%s]", branch.Name, aheadBehindText(branch.Ahead, branch.Behind))
+	}
+
+	return branch.Name
+}
+
+// Opens the commit editor with the draft of the current branch, or the commit template if there is none
+func (app *App) openCommitEditor() {
+	repoPath := app.Repo.Path
+	branchName := app.Repo.CurrentBranch
+
+	app.prepareCommitEditor()
+
+	template := git.GetCommitTemplate(repoPath)
+
+	message := settings.LoadDraft(repoPath, branchName)
+	if message == "" {
+		message = template
+	}
+
+	app.CommitEditor.Open(message, func(message string) {
+		// The draft is kept until the commit succeeds, e.g. a hook may reject it
+		settings.SaveDraft(repoPath, branchName, message)
+
+		changes, err := git.Commit(message, repoPath)
+		if app.reportError(err) {
+			return
+		}
+
+		settings.SaveDraft(repoPath, branchName, "")
+		app.showChanges(changes)
+	}, func(message string) {
+		// An untouched template is not worth keeping, it is loaded again anyway
+		if message == strings.TrimRight(template, " \n") {
+			message = ""
+		}
+
+		settings.SaveDraft(repoPath, branchName, message)
+	})
+}
+
+// Sets up the sign-off and the commit rules of the current repository
+func (app *App) prepareCommitEditor() {
+	repoPath := app.Repo.Path
+
+	app.CommitEditor.SignOff = fmt.Sprintf("Signed-off-by: %s <%s>", git.GetConfig("user.name", repoPath), git.GetConfig("user.email", repoPath))
+
+	rules := app.Settings.GetCommitRules(repoPath)
+	app.CommitEditor.Lint = func(message string) []string {
+		return lintCommitMessage(message, rules)
+	}
+}
+
+func (app *App) handleCommitEditorInput(input *Input) {
+	if input.Ctrl && input.TypedCharacter == 'o' {
+		app.CommandInput.Open("Co-author, e.g. Name <email>", func(author string) {
+			app.CommitEditor.AddTrailer("Co-authored-by: " + author)
+		})
+
+		return
+	}
+
+	app.CommitEditor.Tick(input)
+}
+
+func (app *App) amendCommit(message string) {
+	changes, err := git.AmendCommit(message, app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	app.showChanges(changes)
+	app.refreshRemoteStatus()
+}
+
+// Rewriting a commit that is already pushed means the branch has to be force pushed afterwards, so
+// that has to be confirmed first
+func (app *App) confirmRewriteHead(action string, callback func()) {
+	if !git.IsHeadPushed(app.Repo.Path) {
+		callback()
+		return
+	}
+
+	app.CommandInput.Open(fmt.Sprintf("HEAD is already pushed to %s, type yes to %s it anyway", app.Repo.Upstream, action), func(answer string) {
+		if answer != "yes" {
+			app.ErrorBanner.Show(fmt.Errorf("did not %s HEAD, it is already pushed to %s", action, app.Repo.Upstream))
+			return
+		}
+
+		callback()
+	})
+}
+
+// Asks for the name and message of an annotated tag on commit, or on HEAD if commit has no hash
+func (app *App) createTag(commit git.GitCommit) {
+	target := "HEAD"
+	if commit.Hash != "" {
+		target = commit.ShortHash
+	}
+
+	app.CommandInput.Open("Name of tag on "+target, func(name string) {
+		app.CommandInput.Open("Message for "+name, func(message string) {
+			err := git.CreateTag(name, message, commit.Hash, app.Repo.Path)
+			app.reportError(err)
+			app.refreshTags()
+		})
+	})
+}
+
+func (app *App) refreshTags() {
+	tags, err := git.ListTags(app.Repo.Path)
+	app.reportError(err)
+
+	app.Tags.ShowTags(tags)
+}
+
+func (app *App) checkoutDetached(revision string) {
+	err := git.CheckoutDetached(revision, app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	app.Settings.SetActiveBranch("")
+	app.Settings.Save()
+
+	app.setRepository(app.Repo.Path)
+}
+
+// Calls callback with the only remote, or with the one picked when there are several
+func (app *App) chooseRemote(callback func(remote string)) {
+	remotes, err := git.ListRemotes(app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	if len(remotes) == 0 {
+		app.ErrorBanner.Show(errors.New("this repository has no remotes"))
+	} else if len(remotes) == 1 {
+		callback(remotes[0])
+	} else {
+		app.Search.Open("Remote", remotes, SEARCH_BEGINS_WITH, callback)
+	}
+}
+
+// Shows the current branch, or what HEAD points at when no branch is checked out
+func (app *App) showCurrentBranch() {
+	if app.Repo.CurrentBranch != "" {
+		app.Statusbar.ShowBranchName(app.Repo.CurrentBranch)
+		return
+	}
+
+	name, err := git.GetDetachedHeadName(app.Repo.Path)
+	if err != nil {
+		app.Statusbar.ShowBranchName("")
+		return
+	}
+
+	app.Statusbar.ShowDetachedHead(name)
+}
+
+func (app *App) reloadConflicts() {
+	if len(app.Staging.Entries) == 0 || app.Staging.GetActiveEntry().Type != git.GIT_ENTRY_CONFLICTED {
+		app.setMode(MODE_NORMAL)
+		return
+	}
+
+	conflicts, err := git.ReadConflictFile(app.Staging.GetActiveEntryFileName(), app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	app.ConflictView.ShowConflicts(conflicts)
+}
+
+func (app *App) openHistory() {
+	app.LogView.Clear()
+
+	// The history is read from the start again in case it changed since the last time
+	app.closeHistory()
+
+	var err error
+	app.LogReader, err = git.StartLog(app.Repo.Path)
+	if app.reportError(err) {
+		app.LogView.AllLoaded = true
+	} else {
+		app.loadMoreHistory()
+	}
+
+	app.setMode(MODE_HISTORY)
+}
+
+// Stops the git process that the history is read from
+func (app *App) closeHistory() {
+	if app.LogReader != nil {
+		app.LogReader.Close()
+		app.LogReader = nil
+	}
+}
+
+func (app *App) loadMoreHistory() {
+	app.loadHistory(LOG_PAGE_SIZE)
+}
+
+func (app *App) loadHistory(count int) {
+	if count <= 0 {
+		return
+	}
+
+	if app.LogReader == nil {
+		app.LogView.AllLoaded = true
+		return
+	}
+
+	commits, err := app.LogReader.Next(count)
+	app.LogView.AppendCommits(commits)
+
+	if app.reportError(err) || app.LogReader.Done() {
+		app.LogView.AllLoaded = true
+	}
+}
+
+func (app *App) showCommit(commit git.GitCommit) {
+	files, err := git.CommitFiles(commit, app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	app.ActiveCommit = commit
+	app.CommitFiles.ShowEntries(files)
+	app.CommitFiles.ResetActiveEntry()
+
+	if len(app.CommitFiles.Entries) > 0 {
+		app.showActiveCommitFileDiff()
+	}
+
+	app.setMode(MODE_COMMIT)
+}
+
+func (app *App) showActiveCommitFileDiff() {
+	activeEntry := app.CommitFiles.GetActiveEntry()
+
+	var diff git.GitDiff
+	var sources git.GitDiffSources
+	var err error
+	if app.Mode == MODE_STASH_FILES {
+		diff, err = git.DiffStashEntry(app.ActiveStash, activeEntry, app.diffOptions(), app.Repo.Path)
+		sources = git.GetStashEntrySources(app.ActiveStash, activeEntry, app.Repo.Path)
+	} else {
+		diff, err = git.DiffCommitEntry(app.ActiveCommit, activeEntry, app.diffOptions(), app.Repo.Path)
+		sources = git.GetCommitEntrySources(app.ActiveCommit, activeEntry, app.Repo.Path)
+	}
+	app.reportError(err)
+
+	app.DiffView.ShowDiff(diff, activeEntry, sources)
+}
+
+func (app *App) setRepository(repoPath string) {
+	if repoPath != app.Repo.Path {
+		app.closeHistory()
+	}
+
+	app.Repo.Name = filepath.Base(repoPath)
+	app.Repo.Path = repoPath
+	app.Repo.CurrentBranch = ""
+	app.Repo.Branches = nil
+	app.Repo.Changes = nil
+	app.Repo.Stash = nil
+	app.Repo.Operation = git.GitOperationState{}
+
+	app.Statusbar.ShowRepoName(app.Repo.Name)
+	app.Staging.ShowEntries(app.Repo.Changes)
+	app.Staging.ResetActiveEntry()
+	app.DiffView.ShowWhitespace = app.Settings.GetDiffOptions(repoPath).ShowWhitespace
+
+	var err error
+	app.Repo.CurrentBranch, err = git.GetCurrentBranch(app.Repo.Path)
+	if app.reportError(err) {
+		app.Statusbar.ShowBranchName(app.Repo.CurrentBranch)
+		return
+	}
+
+	app.showCurrentBranch()
+
+	app.Repo.Branches, err = git.ListBranches(app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	app.refreshStash()
+	app.refreshChanges()
+	app.refreshRemoteStatus()
+}
+
+func (app *App) otherBranches() (result []string) {
+	for _, branch := range app.Repo.Branches {
+		if branch != app.Repo.CurrentBranch {
+			result = append(result, branch)
+		}
+	}
+
+	return
+}
+
+func (app *App) cloneRepository(url string, folderPath string) {
+	app.Task.Start("Cloning", func(ctx context.Context, onProgress func(git.GitProgress)) error {
+		return git.Clone(ctx, url, folderPath, onProgress)
+	}, func(err error) {
+		if app.reportRemoteError(err) {
+			return
+		}
+
+		app.setRepository(folderPath)
+		app.Settings.AddRepo(folderPath)
+		app.Settings.SetActiveRepo(folderPath)
+		app.Settings.SetActiveBranch(app.Repo.CurrentBranch)
+		app.Settings.Save()
+	})
+}
+
+func (app *App) refreshRemoteStatus() {
+	app.Repo.Ahead = 0
+	app.Repo.Behind = 0
+
+	var err error
+	app.Repo.Upstream, err = git.GetUpstream(app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	if app.Repo.Upstream != "" {
+		app.Repo.Ahead, app.Repo.Behind, err = git.AheadBehind(app.Repo.Path)
+		app.reportError(err)
+	}
+
+	app.Statusbar.ShowAheadBehind(app.Repo.Upstream, app.Repo.Ahead, app.Repo.Behind)
+}
+
+// Cancelling a fetch, pull or push is not worth a banner
+func (app *App) reportRemoteError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	return app.reportError(err)
+}
+
+func (app *App) refreshChanges() {
+	changes, err := git.Status(app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	app.showChanges(changes)
+	app.refreshOperationState()
+}
+
+func (app *App) refreshOperationState() {
+	var err error
+	app.Repo.Operation, err = git.GetOperationState(app.Repo.Path)
+	app.reportError(err)
+
+	app.Statusbar.ShowOperation(app.Repo.Operation)
+}
+
+func (app *App) refreshStash() {
+	stash, err := git.ListStash(app.Repo.Path)
+	if app.reportError(err) {
+		return
+	}
+
+	app.Repo.Stash = stash
+	app.Stashes.ShowEntries(stash)
+	app.Statusbar.ShowStashExists(git.DoesBranchHaveStash(app.Repo.CurrentBranch, app.Repo.Stash))
+}
+
+func (app *App) showChanges(changes []git.GitStatusEntry) {
+	app.Repo.Changes = changes
+	app.Staging.ShowEntries(app.Repo.Changes)
+
+	if len(app.Staging.Entries) > 0 {
+		app.showActiveEntryDiff()
+	}
+}
+
+func (app *App) showActiveEntryDiff() {
+	activeEntry := app.Staging.GetActiveEntry()
+
+	diff, err := git.DiffEntry(activeEntry, app.diffOptions(), app.Repo.Path)
+	app.reportError(err)
+
+	app.DiffView.ShowDiff(diff, activeEntry, git.GetEntrySources(activeEntry, app.Repo.Path))
+}
+
+func (app *App) diffOptions() git.GitDiffOptions {
+	options := app.Settings.GetDiffOptions(app.Repo.Path)
+
+	return git.GitDiffOptions{
+		ContextLines:      app.Settings.DiffContextLines,
+		IgnoreSpaceChange: options.IgnoreSpaceChange,
+		IgnoreAllSpace:    options.IgnoreAllSpace,
+		IgnoreBlankLines:  options.IgnoreBlankLines,
+		IgnoreCRAtEOL:     options.IgnoreCRAtEOL,
+	}
+}
+
+// Returns true if there was an error to report
+func (app *App) reportError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	app.ErrorBanner.Show(err)
+	return true
 }
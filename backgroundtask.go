@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Runs a long git command, like a fetch or a clone, on another goroutine and shows its progress.
+// Everything that touches the app happens in Tick so that the callbacks run on the main thread.
+type BackgroundTask struct {
+	BGRect *sdl.Rect
+	Rect   *sdl.Rect
+
+	Active   bool
+	Title    string
+	Progress git.GitProgress
+
+	progress     chan git.GitProgress
+	done         chan error
+	cancel       context.CancelFunc
+	DoneCallback func(error)
+}
+
+func NewBackgroundTask(windowWidth int32, windowHeight int32) (result BackgroundTask) {
+	result.BGRect = &sdl.Rect{X: 0, Y: 0, W: windowWidth, H: windowHeight}
+	result.Rect = &sdl.Rect{X: windowWidth/2 - 271, Y: 200, W: 543, H: 76}
+
+	result.Active = false
+
+	return
+}
+
+func (task *BackgroundTask) Resize(windowWidth int32, windowHeight int32) {
+	task.BGRect.W = windowWidth
+	task.BGRect.H = windowHeight
+	task.Rect.X = windowWidth/2 - 271
+}
+
+func (task *BackgroundTask) Start(title string, run func(ctx context.Context, onProgress func(git.GitProgress)) error, callback func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	task.Title = title
+	task.Progress = git.GitProgress{}
+	task.progress = make(chan git.GitProgress, 16)
+	task.done = make(chan error, 1)
+	task.cancel = cancel
+	task.DoneCallback = callback
+
+	task.Active = true
+
+	progress := task.progress
+	done := task.done
+
+	go func() {
+		err := run(ctx, func(p git.GitProgress) {
+			// Dropping an update is fine, a newer one is going to come soon enough
+			select {
+			case progress <- p:
+			default:
+			}
+		})
+
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		done <- err
+	}()
+}
+
+func (task *BackgroundTask) Cancel() {
+	if task.cancel != nil {
+		task.cancel()
+	}
+}
+
+func (task *BackgroundTask) Tick(input *Input) {
+	if input.Escape {
+		task.Cancel()
+	}
+
+	for {
+		select {
+		case p := <-task.progress:
+			task.Progress = p
+		case err := <-task.done:
+			task.Active = false
+			task.cancel()
+
+			if task.DoneCallback != nil {
+				task.DoneCallback(err)
+			}
+
+			return
+		default:
+			return
+		}
+	}
+}
+
+func (task *BackgroundTask) Render(rend *sdl.Renderer, app *App) {
+	if !task.Active {
+		return
+	}
+
+	renderer.DrawRectTransparent(rend, task.BGRect, sdl.Color{R: 0, G: 0, B: 0, A: 102})
+	renderer.DrawRect(rend, task.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+	renderer.DrawRectOutline(rend, task.Rect, sdl.Color{R: 18, G: 17, B: 20, A: 255}, 2)
+
+	titleFont := app.Fonts["14"]
+	phaseFont := app.Fonts["12"]
+
+	title := task.Title + " (esc to cancel)"
+	titleRect := sdl.Rect{
+		X: task.Rect.X + 10,
+		Y: task.Rect.Y + 10,
+		W: titleFont.GetStringWidth(title),
+		H: titleFont.Size,
+	}
+	renderer.DrawText(rend, &titleFont, title, &titleRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+
+	phase := "Starting..."
+	if task.Progress.Phase != "" {
+		phase = fmt.Sprintf("%s %d%%", task.Progress.Phase, task.Progress.Percent)
+		if task.Progress.Total > 0 {
+			phase = fmt.Sprintf("%s (%d/%d)", phase, task.Progress.Current, task.Progress.Total)
+		}
+	}
+
+	phaseRect := sdl.Rect{
+		X: task.Rect.X + 10,
+		Y: titleRect.Y + titleRect.H + 8,
+		W: phaseFont.GetStringWidth(phase),
+		H: phaseFont.Size,
+	}
+	renderer.DrawText(rend, &phaseFont, phase, &phaseRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+
+	barRect := sdl.Rect{
+		X: task.Rect.X + 10,
+		Y: task.Rect.Y + task.Rect.H - 10 - 8,
+		W: task.Rect.W - 20,
+		H: 8,
+	}
+	renderer.DrawRect(rend, &barRect, sdl.Color{R: 30, G: 30, B: 30, A: 255})
+
+	filledRect := barRect
+	filledRect.W = barRect.W * int32(task.Progress.Percent) / 100
+	renderer.DrawRect(rend, &filledRect, sdl.Color{R: 82, G: 153, B: 19, A: 255})
+}
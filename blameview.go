@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type BlameView struct {
+	Rect *sdl.Rect
+
+	Data   git.GitBlame
+	Blocks []git.GitBlameBlock
+
+	// Blames that were open before re-blaming at a parent, so that we can go back to them
+	Previous []git.GitBlame
+
+	ActiveLine   int
+	ScrollOffset int32
+}
+
+func NewBlameView(windowWidth int32, windowHeight int32) (result BlameView) {
+	result.Rect = &sdl.Rect{X: 0, Y: 24 + 2, W: windowWidth, H: windowHeight - 24 - 2}
+
+	return
+}
+
+func (blame *BlameView) Resize(windowWidth int32, windowHeight int32) {
+	blame.Rect.W = windowWidth
+	blame.Rect.H = windowHeight - 24 - 2
+}
+
+func (blame *BlameView) ShowBlame(data git.GitBlame) {
+	blame.Previous = nil
+	blame.show(data)
+}
+
+func (blame *BlameView) PushBlame(data git.GitBlame) {
+	blame.Previous = append(blame.Previous, blame.Data)
+	blame.show(data)
+}
+
+func (blame *BlameView) PopBlame() bool {
+	if len(blame.Previous) == 0 {
+		return false
+	}
+
+	last := len(blame.Previous) - 1
+	blame.show(blame.Previous[last])
+	blame.Previous = blame.Previous[:last]
+
+	return true
+}
+
+func (blame *BlameView) show(data git.GitBlame) {
+	blame.Data = data
+	blame.Blocks = git.GroupBlameLines(data.Lines)
+	blame.ActiveLine = 0
+	blame.ScrollOffset = 0
+}
+
+func (blame *BlameView) HasLines() bool {
+	return len(blame.Data.Lines) > 0
+}
+
+func (blame *BlameView) GetActiveLine() git.GitBlameLine {
+	return blame.Data.Lines[blame.ActiveLine]
+}
+
+func (blame *BlameView) GetActiveCommit() git.GitBlameCommit {
+	return blame.Data.Commits[blame.GetActiveLine().Hash]
+}
+
+func (blame *BlameView) GoToNextLine() {
+	blame.ActiveLine += 1
+	if blame.ActiveLine >= len(blame.Data.Lines) {
+		blame.ActiveLine = len(blame.Data.Lines) - 1
+	}
+
+	blame.scrollToActiveLine()
+}
+
+func (blame *BlameView) GoToPrevLine() {
+	blame.ActiveLine -= 1
+	if blame.ActiveLine < 0 {
+		blame.ActiveLine = 0
+	}
+
+	blame.scrollToActiveLine()
+}
+
+func (blame *BlameView) ScrollDown() {
+	blame.ScrollOffset -= 23
+}
+
+func (blame *BlameView) ScrollUp() {
+	blame.ScrollOffset += 23
+	if blame.ScrollOffset > 0 {
+		blame.ScrollOffset = 0
+	}
+}
+
+func (blame *BlameView) scrollToActiveLine() {
+	top := int32(blame.ActiveLine) * 23
+
+	if top+blame.ScrollOffset < 0 {
+		blame.ScrollOffset = -top
+	} else if top+23+blame.ScrollOffset > blame.Rect.H {
+		blame.ScrollOffset = blame.Rect.H - top - 23
+	}
+
+	if blame.ScrollOffset > 0 {
+		blame.ScrollOffset = 0
+	}
+}
+
+func (blame *BlameView) Render(rend *sdl.Renderer, app *App) {
+	renderer.ClipRect(rend, blame.Rect)
+	renderer.DrawRect(rend, blame.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+
+	mainFont := app.Fonts["12"]
+
+	var lineHeight int32 = 23
+	var gutterWidth int32 = 320
+	var numbersWidth int32 = 50
+
+	gutterRect := sdl.Rect{X: blame.Rect.X, Y: blame.Rect.Y, W: gutterWidth, H: blame.Rect.H}
+	renderer.DrawRect(rend, &gutterRect, sdl.Color{R: 30, G: 30, B: 30, A: 255})
+
+	for blockIndex, block := range blame.Blocks {
+		blockTop := blame.Rect.Y + blame.ScrollOffset + int32(block.StartLine)*lineHeight
+		blockHeight := int32(block.LineCount) * lineHeight
+
+		if blockTop+blockHeight < blame.Rect.Y {
+			continue
+		}
+		if blockTop > blame.Rect.Y+blame.Rect.H {
+			break
+		}
+
+		blockRect := sdl.Rect{X: blame.Rect.X, Y: blockTop, W: gutterWidth, H: blockHeight}
+		if blockIndex%2 == 1 {
+			renderer.DrawRect(rend, &blockRect, sdl.Color{R: 38, G: 38, B: 38, A: 255})
+		}
+
+		separatorRect := sdl.Rect{X: blame.Rect.X, Y: blockTop, W: blame.Rect.W, H: 1}
+		renderer.DrawRect(rend, &separatorRect, sdl.Color{R: 63, G: 63, B: 63, A: 255})
+
+		commit := blame.Data.Commits[block.Hash]
+		info := "Not committed yet"
+		if !git.IsUncommittedHash(block.Hash) {
+			info = fmt.Sprintf("%s %s %s", block.Hash[:7], commit.AuthorName, relativeTime(commit.AuthorDate))
+		}
+
+		info = truncateText(info, int((gutterWidth-20)/mainFont.CharacterWidth))
+		infoRect := sdl.Rect{
+			X: blockRect.X + 10,
+			Y: blockTop + (lineHeight-mainFont.Size)/2,
+			W: mainFont.GetStringWidth(info),
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, info, &infoRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+	}
+
+	firstLine := int(-blame.ScrollOffset / lineHeight)
+	for index := firstLine; index < len(blame.Data.Lines); index += 1 {
+		lineTop := blame.Rect.Y + blame.ScrollOffset + int32(index)*lineHeight
+		if lineTop > blame.Rect.Y+blame.Rect.H {
+			break
+		}
+
+		if index == blame.ActiveLine {
+			activeRect := sdl.Rect{X: blame.Rect.X, Y: lineTop, W: blame.Rect.W, H: lineHeight}
+			renderer.DrawRectTransparent(rend, &activeRect, sdl.Color{R: 38, G: 139, B: 210, A: 49})
+		}
+
+		lineNumberStr := strconv.Itoa(index + 1)
+		lineNumberWidth := mainFont.GetStringWidth(lineNumberStr)
+		lineNumberRect := sdl.Rect{
+			X: blame.Rect.X + gutterWidth + numbersWidth - lineNumberWidth - 10,
+			Y: lineTop + (lineHeight-mainFont.Size)/2,
+			W: lineNumberWidth,
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, lineNumberStr, &lineNumberRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		text := blame.Data.Lines[index].Text
+		textRect := sdl.Rect{
+			X: blame.Rect.X + gutterWidth + numbersWidth + 10,
+			Y: lineTop + (lineHeight-mainFont.Size)/2,
+			W: mainFont.GetStringWidth(text),
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, text, &textRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+	}
+
+	renderer.ClipRect(rend, nil)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type BranchView struct {
+	Rect *sdl.Rect
+
+	Branches     []git.GitBranch
+	ActiveBranch int
+	FirstVisible int
+}
+
+func NewBranchView(windowWidth int32, windowHeight int32) (result BranchView) {
+	result.Rect = &sdl.Rect{X: 0, Y: 24 + 2, W: windowWidth, H: windowHeight - 24 - 2}
+
+	return
+}
+
+func (view *BranchView) Resize(windowWidth int32, windowHeight int32) {
+	view.Rect.W = windowWidth
+	view.Rect.H = windowHeight - 24 - 2
+
+	view.scrollToActiveBranch()
+}
+
+func (view *BranchView) ShowBranches(branches []git.GitBranch) {
+	// Stay on the same branch when the list is sorted differently or reloaded
+	activeName := ""
+	if view.ActiveBranch < len(view.Branches) {
+		activeName = view.Branches[view.ActiveBranch].Name
+	}
+
+	view.Branches = branches
+
+	for index, branch := range view.Branches {
+		if branch.Name == activeName {
+			view.ActiveBranch = index
+		}
+	}
+
+	if view.ActiveBranch >= len(view.Branches) {
+		view.ActiveBranch = len(view.Branches) - 1
+	}
+	if view.ActiveBranch < 0 {
+		view.ActiveBranch = 0
+	}
+
+	view.scrollToActiveBranch()
+}
+
+func (view *BranchView) HasBranches() bool {
+	return len(view.Branches) > 0
+}
+
+func (view *BranchView) GetActiveBranch() git.GitBranch {
+	return view.Branches[view.ActiveBranch]
+}
+
+func (view *BranchView) GoToNextBranch() {
+	if view.ActiveBranch < len(view.Branches)-1 {
+		view.ActiveBranch += 1
+	}
+
+	view.scrollToActiveBranch()
+}
+
+func (view *BranchView) GoToPrevBranch() {
+	if view.ActiveBranch > 0 {
+		view.ActiveBranch -= 1
+	}
+
+	view.scrollToActiveBranch()
+}
+
+func (view *BranchView) visibleRows() int {
+	return int(view.Rect.H / 24)
+}
+
+func (view *BranchView) scrollToActiveBranch() {
+	if view.ActiveBranch < view.FirstVisible {
+		view.FirstVisible = view.ActiveBranch
+	} else if view.ActiveBranch >= view.FirstVisible+view.visibleRows() {
+		view.FirstVisible = view.ActiveBranch - view.visibleRows() + 1
+	}
+}
+
+func (view *BranchView) Render(rend *sdl.Renderer, app *App) {
+	renderer.DrawRect(rend, view.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+	renderer.ClipRect(rend, view.Rect)
+
+	mainFont := app.Fonts["12"]
+
+	var rowHeight int32 = 24
+
+	nameColumnWidth := 0
+	for _, branch := range view.Branches {
+		nameColumnWidth = maxInt(nameColumnWidth, len(branch.Name))
+	}
+
+	top := view.Rect.Y
+	for index := view.FirstVisible; index < len(view.Branches) && top < view.Rect.Y+view.Rect.H; index += 1 {
+		branch := view.Branches[index]
+
+		rowRect := sdl.Rect{X: view.Rect.X, Y: top, W: view.Rect.W, H: rowHeight}
+		if index == view.ActiveBranch {
+			renderer.DrawRect(rend, &rowRect, sdl.Color{R: 77, G: 77, B: 77, A: 255})
+			renderer.DrawRectOutline(rend, &rowRect, sdl.Color{R: 92, G: 91, B: 92, A: 255}, 1)
+		}
+
+		textTop := top + (rowHeight-mainFont.Size)/2
+		left := view.Rect.X + 10
+
+		if branch.Current {
+			currentRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth("*"), H: mainFont.Size}
+			renderer.DrawText(rend, &mainFont, "*", &currentRect, sdl.Color{R: 82, G: 153, B: 19, A: 255})
+		}
+		left += mainFont.CharacterWidth + 10
+
+		nameColor := sdl.Color{R: 221, G: 221, B: 221, A: 255}
+		if branch.Remote {
+			nameColor = sdl.Color{R: 211, G: 54, B: 130, A: 255}
+		}
+
+		nameRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(branch.Name), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, branch.Name, &nameRect, nameColor)
+		left += int32(nameColumnWidth)*mainFont.CharacterWidth + 20
+
+		tracking := branchTrackingText(branch)
+		trackingRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(tracking), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, tracking, &trackingRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		date := relativeTime(time.Unix(branch.LastCommitDate, 0))
+		dateRect := sdl.Rect{
+			X: view.Rect.X + view.Rect.W - 10 - mainFont.GetStringWidth(date),
+			Y: textTop,
+			W: mainFont.GetStringWidth(date),
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, date, &dateRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		top += rowHeight
+	}
+
+	renderer.ClipRect(rend, nil)
+}
+
+// e.g. "origin/master: ahead 1, behind 2"
+func branchTrackingText(branch git.GitBranch) string {
+	if branch.Upstream == "" {
+		return ""
+	}
+
+	if branch.UpstreamGone {
+		return fmt.Sprintf("%s: gone", branch.Upstream)
+	}
+
+	return fmt.Sprintf("%s: %s", branch.Upstream, aheadBehindText(branch.Ahead, branch.Behind))
+}
+
+func aheadBehindText(ahead int, behind int) string {
+	if ahead == 0 && behind == 0 {
+		return "up to date"
+	} else if behind == 0 {
+		return fmt.Sprintf("ahead %d", ahead)
+	} else if ahead == 0 {
+		return fmt.Sprintf("behind %d", behind)
+	}
+
+	return fmt.Sprintf("ahead %d, behind %d", ahead, behind)
+}
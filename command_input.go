@@ -68,6 +68,15 @@ func (ci *CommandInput) Open(placeholder string, callback func(string)) {
 	ci.Active = true
 }
 
+// Opens the input with value already typed in, e.g. to edit an existing commit message
+func (ci *CommandInput) OpenWithValue(placeholder string, value string, callback func(string)) {
+	ci.Open(placeholder, callback)
+
+	ci.Input.Clear()
+	ci.Input.Value.WriteString(value)
+	ci.Result = value
+}
+
 func (ci *CommandInput) Render(rend *sdl.Renderer, app *App) {
 	if !ci.Active {
 		return
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Columns that the subject and the body are conventionally kept within
+const COMMIT_SUBJECT_GUIDE = 50
+const COMMIT_BODY_GUIDE = 72
+
+var commitTrailerPrefixes = []string{"Signed-off-by: ", "Co-authored-by: "}
+
+type CommitEditor struct {
+	BGRect *sdl.Rect
+	Rect   *sdl.Rect
+
+	Lines        []string
+	Trailers     []string
+	CursorLine   int
+	CursorColumn int
+	FirstVisible int
+
+	// The trailer that ctrl + s toggles, e.g. "Signed-off-by: Name <email>"
+	SignOff string
+
+	// Returns what is wrong with a message, the message can still be committed by confirming it
+	Lint            func(string) []string
+	Violations      []string
+	OverridePending bool
+
+	Active         bool
+	SubmitCallback func(string)
+	// Called with the unfinished message when the editor is closed without committing
+	CloseCallback func(string)
+}
+
+func NewCommitEditor(windowWidth int32, windowHeight int32) (result CommitEditor) {
+	result.BGRect = &sdl.Rect{X: 0, Y: 0, W: windowWidth, H: windowHeight}
+	result.Rect = &sdl.Rect{}
+	result.Resize(windowWidth, windowHeight)
+
+	result.Active = false
+
+	return
+}
+
+func (editor *CommitEditor) Resize(windowWidth int32, windowHeight int32) {
+	editor.BGRect.W = windowWidth
+	editor.BGRect.H = windowHeight
+
+	editor.Rect.W = 720
+	if editor.Rect.W > windowWidth-40 {
+		editor.Rect.W = windowWidth - 40
+	}
+	editor.Rect.H = windowHeight - 160
+	if editor.Rect.H < 200 {
+		editor.Rect.H = 200
+	}
+	editor.Rect.X = (windowWidth - editor.Rect.W) / 2
+	editor.Rect.Y = 80
+}
+
+// Opens the editor with message, trailers at the end of it go into the trailer section
+func (editor *CommitEditor) Open(message string, submitCallback func(string), closeCallback func(string)) {
+	editor.Lines, editor.Trailers = splitCommitTrailers(message)
+	editor.CursorLine = 0
+	editor.CursorColumn = len(editor.Lines[0])
+	editor.FirstVisible = 0
+
+	editor.SubmitCallback = submitCallback
+	editor.CloseCallback = closeCallback
+
+	editor.OverridePending = false
+	editor.lint()
+
+	editor.Active = true
+}
+
+func (editor *CommitEditor) Message() string {
+	message := strings.TrimRight(strings.Join(editor.Lines, "\n"), " \n")
+	if len(editor.Trailers) == 0 {
+		return message
+	}
+
+	return message + "\n\n" + strings.Join(editor.Trailers, "\n")
+}
+
+func (editor *CommitEditor) HasMessage() bool {
+	return strings.TrimSpace(strings.Join(editor.Lines, "\n")) != ""
+}
+
+func (editor *CommitEditor) AddTrailer(trailer string) {
+	if !containsString(editor.Trailers, trailer) {
+		editor.Trailers = append(editor.Trailers, trailer)
+	}
+}
+
+func (editor *CommitEditor) ToggleSignOff() {
+	for index, trailer := range editor.Trailers {
+		if trailer == editor.SignOff {
+			editor.Trailers = append(editor.Trailers[:index], editor.Trailers[index+1:]...)
+			return
+		}
+	}
+
+	editor.AddTrailer(editor.SignOff)
+}
+
+func (editor *CommitEditor) RemoveLastTrailer() {
+	if len(editor.Trailers) > 0 {
+		editor.Trailers = editor.Trailers[:len(editor.Trailers)-1]
+	}
+}
+
+func (editor *CommitEditor) Tick(input *Input) {
+	if input.Escape {
+		editor.Active = false
+		editor.CloseCallback(editor.Message())
+
+		return
+	}
+
+	if input.Ctrl && input.TypedCharacter == '\n' {
+		if !editor.HasMessage() {
+			return
+		}
+
+		// Committing with violations has to be confirmed by pressing it again
+		if len(editor.Violations) > 0 && !editor.OverridePending {
+			editor.OverridePending = true
+			return
+		}
+
+		editor.Active = false
+		editor.SubmitCallback(editor.Message())
+
+		return
+	}
+
+	if input.TypedCharacter != 0 || input.Backspace || input.Delete {
+		editor.OverridePending = false
+		defer editor.lint()
+	}
+
+	if input.Ctrl {
+		if input.TypedCharacter == 's' {
+			editor.ToggleSignOff()
+		} else if input.TypedCharacter == 'x' {
+			editor.RemoveLastTrailer()
+		} else if input.Backspace {
+			// Deletes everything before the cursor on the current line
+			line := editor.Lines[editor.CursorLine]
+			editor.Lines[editor.CursorLine] = line[editor.CursorColumn:]
+			editor.CursorColumn = 0
+		}
+
+		return
+	}
+
+	line := editor.Lines[editor.CursorLine]
+
+	if input.Backspace {
+		if editor.CursorColumn > 0 {
+			editor.Lines[editor.CursorLine] = line[:editor.CursorColumn-1] + line[editor.CursorColumn:]
+			editor.CursorColumn -= 1
+		} else if editor.CursorLine > 0 {
+			previous := editor.Lines[editor.CursorLine-1]
+			editor.Lines[editor.CursorLine-1] = previous + line
+			editor.removeLine(editor.CursorLine)
+			editor.CursorLine -= 1
+			editor.CursorColumn = len(previous)
+		}
+	} else if input.Delete {
+		if editor.CursorColumn < len(line) {
+			editor.Lines[editor.CursorLine] = line[:editor.CursorColumn] + line[editor.CursorColumn+1:]
+		} else if editor.CursorLine < len(editor.Lines)-1 {
+			editor.Lines[editor.CursorLine] = line + editor.Lines[editor.CursorLine+1]
+			editor.removeLine(editor.CursorLine + 1)
+		}
+	} else if input.Left {
+		if editor.CursorColumn > 0 {
+			editor.CursorColumn -= 1
+		} else if editor.CursorLine > 0 {
+			editor.CursorLine -= 1
+			editor.CursorColumn = len(editor.Lines[editor.CursorLine])
+		}
+	} else if input.Right {
+		if editor.CursorColumn < len(line) {
+			editor.CursorColumn += 1
+		} else if editor.CursorLine < len(editor.Lines)-1 {
+			editor.CursorLine += 1
+			editor.CursorColumn = 0
+		}
+	} else if input.Up {
+		if editor.CursorLine > 0 {
+			editor.CursorLine -= 1
+		} else {
+			editor.CursorColumn = 0
+		}
+	} else if input.Down {
+		if editor.CursorLine < len(editor.Lines)-1 {
+			editor.CursorLine += 1
+		} else {
+			editor.CursorColumn = len(line)
+		}
+	} else if input.Home {
+		editor.CursorColumn = 0
+	} else if input.End {
+		editor.CursorColumn = len(line)
+	} else if input.TypedCharacter == '\n' {
+		editor.Lines[editor.CursorLine] = line[:editor.CursorColumn]
+		editor.insertLine(editor.CursorLine+1, line[editor.CursorColumn:])
+		editor.CursorLine += 1
+		editor.CursorColumn = 0
+	} else if input.TypedCharacter != 0 && input.TypedCharacter != '\t' {
+		editor.Lines[editor.CursorLine] = line[:editor.CursorColumn] + string(input.TypedCharacter) + line[editor.CursorColumn:]
+		editor.CursorColumn += 1
+	}
+
+	// Moving up or down keeps the column if the new line is long enough
+	if editor.CursorColumn > len(editor.Lines[editor.CursorLine]) {
+		editor.CursorColumn = len(editor.Lines[editor.CursorLine])
+	}
+}
+
+func (editor *CommitEditor) lint() {
+	editor.Violations = nil
+	if editor.Lint != nil {
+		editor.Violations = editor.Lint(editor.Message())
+	}
+}
+
+func (editor *CommitEditor) insertLine(index int, line string) {
+	editor.Lines = append(editor.Lines, "")
+	copy(editor.Lines[index+1:], editor.Lines[index:])
+	editor.Lines[index] = line
+}
+
+func (editor *CommitEditor) removeLine(index int) {
+	editor.Lines = append(editor.Lines[:index], editor.Lines[index+1:]...)
+}
+
+func (editor *CommitEditor) Render(rend *sdl.Renderer, app *App) {
+	if !editor.Active {
+		return
+	}
+
+	renderer.DrawRectTransparent(rend, editor.BGRect, sdl.Color{R: 0, G: 0, B: 0, A: 102})
+	renderer.DrawRect(rend, editor.Rect, sdl.Color{R: 18, G: 17, B: 20, A: 255})
+
+	mainFont := app.Fonts["14"]
+	smallFont := app.Fonts["12"]
+
+	var headerHeight int32 = 26
+	var lineHeight int32 = 20
+	var trailerHeight int32 = int32(len(editor.Trailers)+1) * lineHeight
+	var violationsHeight int32 = int32(len(editor.Violations)) * lineHeight
+	if editor.OverridePending {
+		violationsHeight += lineHeight
+	}
+	var footerHeight int32 = 24
+
+	// Title with the length of the subject, which turns orange once it is longer than it should be
+	subjectLength := utf8.RuneCountInString(editor.Lines[0])
+	title := fmt.Sprintf("Commit message  %d/%d", subjectLength, COMMIT_SUBJECT_GUIDE)
+	titleColor := sdl.Color{R: 171, G: 171, B: 171, A: 255}
+	if subjectLength > COMMIT_SUBJECT_GUIDE {
+		titleColor = sdl.Color{R: 203, G: 75, B: 22, A: 255}
+	}
+	titleRect := sdl.Rect{
+		X: editor.Rect.X + 10,
+		Y: editor.Rect.Y + (headerHeight-mainFont.Size)/2,
+		W: mainFont.GetStringWidth(title),
+		H: mainFont.Size,
+	}
+	renderer.DrawText(rend, &mainFont, title, &titleRect, titleColor)
+
+	textRect := sdl.Rect{
+		X: editor.Rect.X + 2,
+		Y: editor.Rect.Y + headerHeight,
+		W: editor.Rect.W - 4,
+		H: editor.Rect.H - headerHeight - violationsHeight - trailerHeight - footerHeight,
+	}
+	renderer.DrawRect(rend, &textRect, sdl.Color{R: 32, G: 33, B: 35, A: 255})
+
+	visibleLines := int(textRect.H / lineHeight)
+	if editor.CursorLine < editor.FirstVisible {
+		editor.FirstVisible = editor.CursorLine
+	} else if visibleLines > 0 && editor.CursorLine >= editor.FirstVisible+visibleLines {
+		editor.FirstVisible = editor.CursorLine - visibleLines + 1
+	}
+
+	renderer.ClipRect(rend, &textRect)
+
+	textLeft := textRect.X + 8
+	for _, guide := range []int32{COMMIT_SUBJECT_GUIDE, COMMIT_BODY_GUIDE} {
+		guideLeft := textLeft + guide*mainFont.CharacterWidth
+		renderer.DrawLine(rend, &sdl.Point{X: guideLeft, Y: textRect.Y}, &sdl.Point{X: guideLeft, Y: textRect.Y + textRect.H}, sdl.Color{R: 63, G: 63, B: 63, A: 255})
+	}
+
+	top := textRect.Y
+	for index := editor.FirstVisible; index < len(editor.Lines) && top < textRect.Y+textRect.H; index += 1 {
+		line := editor.Lines[index]
+		textTop := top + (lineHeight-mainFont.Size)/2
+
+		if line != "" {
+			lineRect := sdl.Rect{X: textLeft, Y: textTop, W: mainFont.GetStringWidth(line), H: mainFont.Size}
+			renderer.DrawText(rend, &mainFont, line, &lineRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+		}
+
+		if index == editor.CursorLine {
+			cursorRect := sdl.Rect{
+				X: textLeft + mainFont.GetStringWidth(line[:editor.CursorColumn]) - 1,
+				Y: top + 2,
+				W: 1,
+				H: lineHeight - 4,
+			}
+			renderer.DrawRect(rend, &cursorRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+		}
+
+		top += lineHeight
+	}
+
+	renderer.ClipRect(rend, nil)
+
+	top = textRect.Y + textRect.H
+	for _, violation := range editor.Violations {
+		violationRect := sdl.Rect{X: textLeft, Y: top + (lineHeight-smallFont.Size)/2, W: smallFont.GetStringWidth(violation), H: smallFont.Size}
+		renderer.DrawText(rend, &smallFont, violation, &violationRect, sdl.Color{R: 203, G: 75, B: 22, A: 255})
+		top += lineHeight
+	}
+
+	if editor.OverridePending {
+		confirmation := "Press ctrl+enter again to commit anyway"
+		confirmationRect := sdl.Rect{X: textLeft, Y: top + (lineHeight-smallFont.Size)/2, W: smallFont.GetStringWidth(confirmation), H: smallFont.Size}
+		renderer.DrawText(rend, &smallFont, confirmation, &confirmationRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+		top += lineHeight
+	}
+
+	trailersTitle := "Trailers"
+	if len(editor.Trailers) == 0 {
+		trailersTitle = "No trailers"
+	}
+	trailersTitleRect := sdl.Rect{X: textLeft, Y: top + (lineHeight-smallFont.Size)/2, W: smallFont.GetStringWidth(trailersTitle), H: smallFont.Size}
+	renderer.DrawText(rend, &smallFont, trailersTitle, &trailersTitleRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+	top += lineHeight
+
+	for _, trailer := range editor.Trailers {
+		trailerRect := sdl.Rect{X: textLeft, Y: top + (lineHeight-mainFont.Size)/2, W: mainFont.GetStringWidth(trailer), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, trailer, &trailerRect, sdl.Color{R: 42, G: 161, B: 152, A: 255})
+		top += lineHeight
+	}
+
+	help := "ctrl+enter commit   ctrl+s sign off   ctrl+o co-author   ctrl+x remove trailer   esc keep as draft"
+	helpRect := sdl.Rect{
+		X: editor.Rect.X + 10,
+		Y: editor.Rect.Y + editor.Rect.H - footerHeight + (footerHeight-smallFont.Size)/2,
+		W: smallFont.GetStringWidth(help),
+		H: smallFont.Size,
+	}
+	renderer.DrawText(rend, &smallFont, help, &helpRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+}
+
+// Splits off the trailers at the end of a message, the message always has at least one line
+func splitCommitTrailers(message string) (lines []string, trailers []string) {
+	lines = strings.Split(strings.TrimRight(message, "\n"), "\n")
+
+	end := len(lines)
+	for end > 0 && isCommitTrailer(lines[end-1]) {
+		end -= 1
+	}
+
+	// Trailers are only recognized in their own paragraph
+	if end < len(lines) && (end == 0 || strings.TrimSpace(lines[end-1]) == "") {
+		trailers = append(trailers, lines[end:]...)
+		lines = lines[:end]
+	}
+
+	lines = strings.Split(strings.TrimRight(strings.Join(lines, "\n"), "\n"), "\n")
+
+	return
+}
+
+func isCommitTrailer(line string) bool {
+	for _, prefix := range commitTrailerPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DonutLaser/git-client/settings"
+)
+
+// Returns what is wrong with message according to the rules of the repository
+func lintCommitMessage(message string, rules settings.CommitRules) (result []string) {
+	lines := strings.Split(message, "\n")
+	subject := lines[0]
+
+	if len(rules.Types) > 0 && !containsString(rules.Types, conventionalCommitType(subject)) {
+		result = append(result, fmt.Sprintf("Subject has to start with one of %s, e.g. \"%s: ...\"", strings.Join(rules.Types, ", "), rules.Types[0]))
+	}
+
+	if subjectLength := utf8.RuneCountInString(subject); rules.MaxSubjectLength > 0 && subjectLength > rules.MaxSubjectLength {
+		result = append(result, fmt.Sprintf("Subject is %d characters long, at most %d are allowed", subjectLength, rules.MaxSubjectLength))
+	}
+
+	if rules.BlankLineBeforeBody && len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		result = append(result, "Body has to be separated from the subject by a blank line")
+	}
+
+	if rules.NoTrailingPeriod && strings.HasSuffix(strings.TrimSpace(subject), ".") {
+		result = append(result, "Subject must not end with a period")
+	}
+
+	return
+}
+
+// "feat(parser)!: add arrays" has the type "feat", a subject without a type gives ""
+func conventionalCommitType(subject string) string {
+	prefix, _, found := strings.Cut(subject, ": ")
+	if !found {
+		return ""
+	}
+
+	prefix = strings.TrimSuffix(prefix, "!")
+
+	if index := strings.Index(prefix, "("); index >= 0 {
+		if !strings.HasSuffix(prefix, ")") {
+			return ""
+		}
+
+		prefix = prefix[:index]
+	}
+
+	return prefix
+}
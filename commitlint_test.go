@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DonutLaser/git-client/settings"
+)
+
+func TestLintCommitMessage(t *testing.T) {
+	rules := settings.CommitRules{
+		Types:               []string{"feat", "fix"},
+		MaxSubjectLength:    20,
+		BlankLineBeforeBody: true,
+		NoTrailingPeriod:    true,
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"valid", "fix(ui): löse Fehler\n\nBody", nil},
+		{"multibyte subject at the limit", "feat: ünïcödé äöü ßß", nil},
+		{"multibyte subject over the limit", "feat: ünïcödé äöü ßßß", []string{"Subject is 21 characters long, at most 20 are allowed"}},
+		{"unknown type", "docs: readme", []string{"Subject has to start with one of feat, fix, e.g. \"feat: ...\""}},
+		{"breaking change with a scope", "feat(api)!: drop v1", nil},
+		{"body right after the subject", "fix: typo\nBody", []string{"Body has to be separated from the subject by a blank line"}},
+		{"trailing period", "fix: typo.", []string{"Subject must not end with a period"}},
+	}
+
+	for _, test := range tests {
+		got := lintCommitMessage(test.message, rules)
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if got := lintCommitMessage("anything goes.\nright here", settings.CommitRules{}); got != nil {
+		t.Errorf("rules without checks found %q", got)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type ConflictView struct {
+	OursRect   *sdl.Rect
+	TheirsRect *sdl.Rect
+	ResultRect *sdl.Rect
+
+	Data git.GitConflictFile
+
+	// Index into Data.Sections, always points to a conflict if there is one
+	ActiveSection int
+	ScrollOffset  int32
+}
+
+func NewConflictView(windowWidth int32, windowHeight int32) (result ConflictView) {
+	width := (windowWidth - 280 - 2*3) / 3
+	height := windowHeight - 24 - 2
+
+	result.OursRect = &sdl.Rect{X: 280 + 2, Y: 24 + 2, W: width, H: height}
+	result.TheirsRect = &sdl.Rect{X: result.OursRect.X + result.OursRect.W + 2, Y: 24 + 2, W: width, H: height}
+	result.ResultRect = &sdl.Rect{X: result.TheirsRect.X + result.TheirsRect.W + 2, Y: 24 + 2, W: width, H: height}
+
+	return
+}
+
+func (view *ConflictView) Resize(windowWidth int32, windowHeight int32) {
+	width := (windowWidth - 280 - 2*3) / 3
+	height := windowHeight - 24 - 2
+
+	view.OursRect.W = width
+	view.OursRect.H = height
+	view.TheirsRect.X = view.OursRect.X + view.OursRect.W + 2
+	view.TheirsRect.W = width
+	view.TheirsRect.H = height
+	view.ResultRect.X = view.TheirsRect.X + view.TheirsRect.W + 2
+	view.ResultRect.W = width
+	view.ResultRect.H = height
+}
+
+func (view *ConflictView) ShowConflicts(data git.GitConflictFile) {
+	// Stay around the same place when the same file is reloaded, e.g. after editing it
+	if data.Filename != view.Data.Filename {
+		view.ActiveSection = 0
+		view.ScrollOffset = 0
+	}
+
+	view.Data = data
+
+	if view.ActiveSection >= len(view.Data.Sections) {
+		view.ActiveSection = len(view.Data.Sections) - 1
+	}
+	if view.ActiveSection < 0 {
+		view.ActiveSection = 0
+	}
+
+	if !view.isActiveConflict() {
+		view.GoToNextConflict()
+	}
+}
+
+func (view *ConflictView) HasConflicts() bool {
+	return view.Data.ConflictCount() > 0
+}
+
+func (view *ConflictView) Resolve(resolution git.GitConflictResolution) {
+	if !view.isActiveConflict() {
+		return
+	}
+
+	view.Data.Sections[view.ActiveSection].Resolution = resolution
+}
+
+func (view *ConflictView) GoToNextConflict() {
+	for index := view.ActiveSection + 1; index < len(view.Data.Sections); index += 1 {
+		if view.Data.Sections[index].IsConflict {
+			view.ActiveSection = index
+			break
+		}
+	}
+
+	view.scrollToActiveSection()
+}
+
+func (view *ConflictView) GoToPrevConflict() {
+	for index := view.ActiveSection - 1; index >= 0; index -= 1 {
+		if view.Data.Sections[index].IsConflict {
+			view.ActiveSection = index
+			break
+		}
+	}
+
+	view.scrollToActiveSection()
+}
+
+func (view *ConflictView) ScrollDown() {
+	view.ScrollOffset -= 23
+}
+
+func (view *ConflictView) ScrollUp() {
+	view.ScrollOffset += 23
+	if view.ScrollOffset > 0 {
+		view.ScrollOffset = 0
+	}
+}
+
+func (view *ConflictView) isActiveConflict() bool {
+	return view.ActiveSection < len(view.Data.Sections) && view.Data.Sections[view.ActiveSection].IsConflict
+}
+
+func (view *ConflictView) scrollToActiveSection() {
+	var top int32 = 0
+	for index := 0; index < view.ActiveSection && index < len(view.Data.Sections); index += 1 {
+		top += int32(view.sectionRowCount(view.Data.Sections[index])) * 23
+	}
+
+	// Leave a few lines of context above the conflict
+	view.ScrollOffset = -top + 3*23
+	if view.ScrollOffset > 0 {
+		view.ScrollOffset = 0
+	}
+}
+
+// All three panes give a section the same number of rows so that the conflicts line up
+func (view *ConflictView) sectionRowCount(section git.GitConflictSection) int {
+	if !section.IsConflict {
+		return len(section.Common)
+	}
+
+	count := maxInt(len(section.Ours), len(section.Theirs))
+	if section.Resolution != git.GIT_RESOLUTION_NONE {
+		count = maxInt(count, len(section.ResolvedLines()))
+	}
+
+	return maxInt(count, 1)
+}
+
+func (view *ConflictView) Render(rend *sdl.Renderer, app *App) {
+	oursLabel, theirsLabel := "", ""
+	for _, section := range view.Data.Sections {
+		if section.IsConflict {
+			oursLabel = section.OursLabel
+			theirsLabel = section.TheirsLabel
+			break
+		}
+	}
+
+	view.renderPane(rend, view.OursRect, paneTitle("Ours", oursLabel), func(section git.GitConflictSection) ([]string, bool) {
+		return section.Ours, true
+	}, sdl.Color{R: 38, G: 139, B: 210, A: 49}, app)
+
+	view.renderPane(rend, view.TheirsRect, paneTitle("Theirs", theirsLabel), func(section git.GitConflictSection) ([]string, bool) {
+		return section.Theirs, true
+	}, sdl.Color{R: 211, G: 54, B: 130, A: 49}, app)
+
+	resultTitle := fmt.Sprintf("Result (%d of %d unresolved)", view.Data.UnresolvedCount(), view.Data.ConflictCount())
+	view.renderPane(rend, view.ResultRect, resultTitle, func(section git.GitConflictSection) ([]string, bool) {
+		if section.Resolution == git.GIT_RESOLUTION_NONE {
+			return nil, false
+		}
+
+		return section.ResolvedLines(), true
+	}, sdl.Color{R: 82, G: 153, B: 19, A: 49}, app)
+}
+
+// conflictLines returns the lines a pane shows for a conflict, or false if the conflict has nothing to show yet
+func (view *ConflictView) renderPane(rend *sdl.Renderer, paneRect *sdl.Rect, title string, conflictLines func(git.GitConflictSection) ([]string, bool), conflictColor sdl.Color, app *App) {
+	renderer.ClipRect(rend, paneRect)
+	renderer.DrawRect(rend, paneRect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+
+	mainFont := app.Fonts["12"]
+
+	var headerHeight int32 = 22
+	var lineHeight int32 = 23
+
+	if !view.HasConflicts() {
+		message := "No conflict markers left"
+		textRect := sdl.Rect{
+			X: paneRect.X + (paneRect.W-mainFont.GetStringWidth(message))/2,
+			Y: paneRect.Y + (paneRect.H-mainFont.Size)/2,
+			W: mainFont.GetStringWidth(message),
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, message, &textRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		renderer.ClipRect(rend, nil)
+		return
+	}
+
+	numbersRect := sdl.Rect{
+		X: paneRect.X,
+		Y: paneRect.Y + headerHeight,
+		W: 40,
+		H: paneRect.H - headerHeight,
+	}
+	renderer.DrawRect(rend, &numbersRect, sdl.Color{R: 30, G: 30, B: 30, A: 255})
+
+	lineNumber := 1
+	lineTop := numbersRect.Y + view.ScrollOffset
+	for sectionIndex, section := range view.Data.Sections {
+		rowCount := view.sectionRowCount(section)
+
+		lines := section.Common
+		hasLines := true
+		if section.IsConflict {
+			lines, hasLines = conflictLines(section)
+
+			blockRect := sdl.Rect{
+				X: paneRect.X,
+				Y: lineTop,
+				W: paneRect.W,
+				H: int32(rowCount) * lineHeight,
+			}
+
+			if hasLines {
+				renderer.DrawRectTransparent(rend, &blockRect, conflictColor)
+			} else {
+				renderer.DrawRectTransparent(rend, &blockRect, sdl.Color{R: 169, G: 26, B: 23, A: 49})
+
+				message := "Unresolved"
+				messageRect := sdl.Rect{
+					X: numbersRect.X + numbersRect.W + 10,
+					Y: lineTop + (lineHeight-mainFont.Size)/2,
+					W: mainFont.GetStringWidth(message),
+					H: mainFont.Size,
+				}
+				renderer.DrawText(rend, &mainFont, message, &messageRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+			}
+
+			if sectionIndex == view.ActiveSection {
+				renderer.DrawRectOutline(rend, &blockRect, sdl.Color{R: 38, G: 139, B: 210, A: 255}, 1)
+			}
+		}
+
+		for _, line := range lines {
+			if lineTop+lineHeight >= numbersRect.Y && lineTop < paneRect.Y+paneRect.H {
+				lineNumberStr := strconv.Itoa(lineNumber)
+
+				lineNumberWidth := mainFont.GetStringWidth(lineNumberStr)
+				lineNumberRect := sdl.Rect{
+					X: numbersRect.X + numbersRect.W - lineNumberWidth - 10,
+					Y: lineTop + (lineHeight-mainFont.Size)/2,
+					W: lineNumberWidth,
+					H: mainFont.Size,
+				}
+				renderer.DrawText(rend, &mainFont, lineNumberStr, &lineNumberRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+
+				text := strings.TrimRight(line, "\r\n")
+				textRect := sdl.Rect{
+					X: numbersRect.X + numbersRect.W + 10,
+					Y: lineTop + (lineHeight-mainFont.Size)/2,
+					W: mainFont.GetStringWidth(text),
+					H: mainFont.Size,
+				}
+				renderer.DrawText(rend, &mainFont, text, &textRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+			}
+
+			lineTop += lineHeight
+			lineNumber += 1
+		}
+
+		// Pad the section so that the next one starts on the same row in every pane
+		lineTop += int32(rowCount-len(lines)) * lineHeight
+	}
+
+	headerRect := sdl.Rect{X: paneRect.X, Y: paneRect.Y, W: paneRect.W, H: headerHeight}
+	renderer.DrawRect(rend, &headerRect, sdl.Color{R: 63, G: 63, B: 63, A: 255})
+
+	titleRect := sdl.Rect{
+		X: headerRect.X + 10,
+		Y: headerRect.Y + (headerHeight-mainFont.Size)/2,
+		W: mainFont.GetStringWidth(title),
+		H: mainFont.Size,
+	}
+	renderer.DrawText(rend, &mainFont, title, &titleRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+
+	renderer.ClipRect(rend, nil)
+}
+
+func paneTitle(side string, label string) string {
+	if label == "" {
+		return side
+	}
+
+	return fmt.Sprintf("%s (%s)", side, label)
+}
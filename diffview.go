@@ -2,46 +2,387 @@ package main
 
 import (
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/DonutLaser/git-client/font"
 	"github.com/DonutLaser/git-client/git"
 	"github.com/DonutLaser/git-client/renderer"
+	"github.com/DonutLaser/git-client/settings"
+	"github.com/DonutLaser/git-client/syntax"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+// Height of the bar above every chunk, which also shows how many lines are hidden between chunks
+const DIFF_SEPARATOR_HEIGHT = 18
+
+// Number of hidden lines that are shown each time the context around a chunk is expanded
+const DIFF_CONTEXT_STEP = 10
+
 type DiffView struct {
-	OldRect *sdl.Rect
-	NewRect *sdl.Rect
+	HeaderRect  *sdl.Rect
+	OldRect     *sdl.Rect
+	NewRect     *sdl.Rect
+	UnifiedRect *sdl.Rect
+
+	// Old and new are shown in a single pane with DIFF_LAYOUT_UNIFIED, or with DIFF_LAYOUT_AUTO when
+	// the window is narrower than UnifiedBelowWidth
+	Layout            settings.DiffLayout
+	UnifiedBelowWidth int32
+	WindowWidth       int32
 
 	Data  git.GitDiff
 	Entry git.GitStatusEntry
 
+	// Both sides as whole files, where expanded context is read from
+	Sources git.GitDiffSources
+
+	// Every chunk is expanded until the whole file is shown
+	WholeFile bool
+
+	// Spaces, tabs and carriage returns in changed lines are drawn as glyphs
+	ShowWhitespace bool
+
+	// Syntax tokens of the lines in Data by chunk and line, nil if the language is not known
+	OldTokens [][][]syntax.Token
+	NewTokens [][][]syntax.Token
+
+	// Rows of the unified layout by chunk
+	UnifiedChunks [][]unifiedRow
+
+	ActiveChunk  int
 	ScrollOffset int32
+
+	// Whether changes within paired lines are highlighted by word or by character
+	Granularity git.GitDiffGranularity
+
+	// Line mode puts a cursor on a single row of the active chunk, rows between SelectionStart
+	// and ActiveLine are selected while Selecting is on
+	LineMode       bool
+	Selecting      bool
+	ActiveLine     int
+	SelectionStart int
 }
 
 func NewDiffView(windowWidth int32, windowHeight int32) (result DiffView) {
 	width := (windowWidth - 280 - 2) / 2
-	height := windowHeight - 24 - 2
+	height := windowHeight - 24 - 2 - 24 - 2
 
-	result.OldRect = &sdl.Rect{X: 280 + 2, Y: 24 + 2, W: width, H: height}
-	result.NewRect = &sdl.Rect{X: result.OldRect.X + result.OldRect.W + 2, Y: 24 + 2, W: width, H: height}
+	result.HeaderRect = &sdl.Rect{X: 280 + 2, Y: 24 + 2, W: windowWidth - 280 - 2, H: 24}
+	result.OldRect = &sdl.Rect{X: 280 + 2, Y: 24 + 2 + 24 + 2, W: width, H: height}
+	result.NewRect = &sdl.Rect{X: result.OldRect.X + result.OldRect.W + 2, Y: 24 + 2 + 24 + 2, W: width, H: height}
+	result.UnifiedRect = &sdl.Rect{X: 280 + 2, Y: 24 + 2 + 24 + 2, W: windowWidth - 280 - 2, H: height}
+	result.WindowWidth = windowWidth
 
 	return
 }
 
 func (diff *DiffView) Resize(windowWidth int32, windowHeight int32) {
 	width := (windowWidth - 280 - 2) / 2
-	height := windowHeight - 24 - 2
+	height := windowHeight - 24 - 2 - 24 - 2
 
+	diff.HeaderRect.W = windowWidth - 280 - 2
 	diff.OldRect.W = width
 	diff.OldRect.H = height
 	diff.NewRect.X = diff.OldRect.X + diff.OldRect.W + 2
 	diff.NewRect.W = width
 	diff.NewRect.H = height
+	diff.UnifiedRect.W = windowWidth - 280 - 2
+	diff.UnifiedRect.H = height
+	diff.WindowWidth = windowWidth
 }
 
-func (diff *DiffView) ShowDiff(data git.GitDiff, entry git.GitStatusEntry) {
+func (diff *DiffView) ShowDiff(data git.GitDiff, entry git.GitStatusEntry, sources git.GitDiffSources) {
+	// Stay on the same hunk when the same file is shown again, e.g. after staging one of its hunks
+	if entry.Filename != diff.Entry.Filename || entry.Staged != diff.Entry.Staged {
+		diff.ActiveChunk = 0
+		diff.ActiveLine = 0
+		diff.ScrollOffset = 0
+	}
+
 	diff.Data = data
 	diff.Entry = entry
+	diff.Sources = sources
+	diff.Data.MarkChangedRanges(diff.Granularity)
+
+	if diff.WholeFile {
+		diff.expandAll()
+	}
+
+	diff.refreshLines()
+
+	if diff.ActiveChunk >= len(diff.Data.RawChunks) {
+		diff.ActiveChunk = len(diff.Data.RawChunks) - 1
+	}
+	if diff.ActiveChunk < 0 {
+		diff.ActiveChunk = 0
+	}
+
+	if diff.ActiveLine >= diff.activeChunkLength() {
+		diff.ActiveLine = diff.activeChunkLength() - 1
+	}
+	if diff.ActiveLine < 0 {
+		diff.ActiveLine = 0
+	}
+
+	diff.Selecting = false
+}
+
+// Tokens and unified rows have to be rebuilt whenever lines are added to the chunks
+func (diff *DiffView) refreshLines() {
+	language := syntax.ForFile(diff.Entry.Filename)
+	diff.OldTokens = highlightChunks(language, diff.Data.OldChunks, diff.Sources.Old)
+	diff.NewTokens = highlightChunks(language, diff.Data.NewChunks, diff.Sources.New)
+	diff.UnifiedChunks = diff.buildUnifiedChunks()
+}
+
+func (diff *DiffView) ExpandContextAbove() {
+	if !diff.HasChunks() {
+		return
+	}
+
+	count := diff.Data.ExpandAbove(diff.ActiveChunk, DIFF_CONTEXT_STEP, diff.Sources)
+	if count == 0 {
+		return
+	}
+
+	// The cursor stays on the line it was on, which moved down
+	diff.ActiveLine += count
+	diff.SelectionStart += count
+
+	diff.refreshLines()
+	diff.scrollToActiveLine()
+}
+
+func (diff *DiffView) ExpandContextBelow() {
+	if !diff.HasChunks() {
+		return
+	}
+
+	if diff.Data.ExpandBelow(diff.ActiveChunk, DIFF_CONTEXT_STEP, diff.Sources) > 0 {
+		diff.refreshLines()
+	}
+}
+
+func (diff *DiffView) ToggleWholeFile() {
+	diff.WholeFile = !diff.WholeFile
+}
+
+func (diff *DiffView) expandAll() {
+	for chIndex := range diff.Data.NewChunks {
+		diff.Data.ExpandAbove(chIndex, diff.Data.HiddenLinesAbove(chIndex), diff.Sources)
+	}
+
+	last := len(diff.Data.NewChunks) - 1
+	diff.Data.ExpandBelow(last, diff.Data.HiddenLinesBelow(last, diff.Sources), diff.Sources)
+}
+
+func (diff *DiffView) IsUnified() bool {
+	if diff.Layout == settings.DIFF_LAYOUT_AUTO {
+		return diff.WindowWidth < diff.UnifiedBelowWidth
+	}
+
+	return diff.Layout == settings.DIFF_LAYOUT_UNIFIED
+}
+
+// Switches to the other layout than the one shown. Switching to what the window width would pick
+// anyway goes back to following the width.
+func (diff *DiffView) ToggleLayout() {
+	auto := diff.WindowWidth < diff.UnifiedBelowWidth
+
+	if diff.IsUnified() {
+		diff.Layout = settings.DIFF_LAYOUT_SPLIT
+	} else {
+		diff.Layout = settings.DIFF_LAYOUT_UNIFIED
+	}
+
+	if diff.IsUnified() == auto {
+		diff.Layout = settings.DIFF_LAYOUT_AUTO
+	}
+
+	diff.scrollToActiveLine()
+}
+
+func (diff *DiffView) ToggleGranularity() {
+	if diff.Granularity == git.GIT_DIFF_WORDS {
+		diff.Granularity = git.GIT_DIFF_CHARACTERS
+	} else {
+		diff.Granularity = git.GIT_DIFF_WORDS
+	}
+
+	diff.Data.MarkChangedRanges(diff.Granularity)
+}
+
+func (diff *DiffView) HasChunks() bool {
+	return len(diff.Data.RawChunks) > 0
+}
+
+func (diff *DiffView) EnterLineMode() {
+	diff.LineMode = true
+	diff.Selecting = false
+	diff.ActiveLine = 0
+
+	diff.scrollToActiveLine()
+}
+
+func (diff *DiffView) ExitLineMode() {
+	diff.LineMode = false
+	diff.Selecting = false
+}
+
+func (diff *DiffView) ToggleSelection() {
+	if diff.Selecting {
+		diff.Selecting = false
+		return
+	}
+
+	diff.Selecting = true
+	diff.SelectionStart = diff.ActiveLine
+}
+
+func (diff *DiffView) GoToNextLine() {
+	diff.ActiveLine += 1
+
+	if diff.ActiveLine >= diff.activeChunkLength() {
+		// A selection can't span several chunks because each chunk is applied as its own patch
+		if diff.Selecting || diff.ActiveChunk >= len(diff.Data.NewChunks)-1 {
+			diff.ActiveLine = diff.activeChunkLength() - 1
+		} else {
+			diff.ActiveChunk += 1
+			diff.ActiveLine = 0
+		}
+	}
+
+	diff.scrollToActiveLine()
+}
+
+func (diff *DiffView) GoToPrevLine() {
+	diff.ActiveLine -= 1
+
+	if diff.ActiveLine < 0 {
+		if diff.Selecting || diff.ActiveChunk == 0 {
+			diff.ActiveLine = 0
+		} else {
+			diff.ActiveChunk -= 1
+			diff.ActiveLine = diff.activeChunkLength() - 1
+		}
+	}
+
+	diff.scrollToActiveLine()
+}
+
+// Returns the indices into Data.RawChunks[ActiveChunk] of the added and removed lines in the selection
+func (diff *DiffView) GetSelectedLines() (result []int) {
+	if !diff.HasChunks() || diff.ActiveChunk >= len(diff.Data.NewChunks) {
+		return
+	}
+
+	first, last := diff.selectionRange()
+
+	oldLines := diff.Data.OldChunks[diff.ActiveChunk].Lines
+	newLines := diff.Data.NewChunks[diff.ActiveChunk].Lines
+	for row := first; row <= last && row < len(newLines); row += 1 {
+		if oldLines[row].Type == git.GIT_LINE_REMOVED {
+			result = append(result, oldLines[row].RawIndex)
+		}
+		if newLines[row].Type == git.GIT_LINE_NEW {
+			result = append(result, newLines[row].RawIndex)
+		}
+	}
+
+	return
+}
+
+func (diff *DiffView) selectionRange() (int, int) {
+	if !diff.Selecting {
+		return diff.ActiveLine, diff.ActiveLine
+	}
+
+	if diff.SelectionStart < diff.ActiveLine {
+		return diff.SelectionStart, diff.ActiveLine
+	}
+
+	return diff.ActiveLine, diff.SelectionStart
+}
+
+func (diff *DiffView) activeChunkLength() int {
+	if diff.ActiveChunk >= len(diff.Data.NewChunks) {
+		return 0
+	}
+
+	return len(diff.Data.NewChunks[diff.ActiveChunk].Lines)
+}
+
+func (diff *DiffView) scrollToActiveLine() {
+	var top int32 = 0
+	for index := 0; index < diff.ActiveChunk && index < len(diff.Data.NewChunks); index += 1 {
+		top += DIFF_SEPARATOR_HEIGHT + int32(diff.chunkRowCount(index))*23
+	}
+	top += DIFF_SEPARATOR_HEIGHT + int32(diff.activeRow())*23
+
+	if top+diff.ScrollOffset < 0 {
+		diff.ScrollOffset = -top + DIFF_SEPARATOR_HEIGHT
+	} else if top+23+diff.ScrollOffset > diff.NewRect.H {
+		diff.ScrollOffset = diff.NewRect.H - top - 23
+	}
+
+	if diff.ScrollOffset > 0 {
+		diff.ScrollOffset = 0
+	}
+}
+
+// The unified layout has a row for each side of a changed line, the side by side one shows both in one row
+func (diff *DiffView) chunkRowCount(chunk int) int {
+	if diff.IsUnified() && chunk < len(diff.UnifiedChunks) {
+		return len(diff.UnifiedChunks[chunk])
+	}
+
+	return len(diff.Data.NewChunks[chunk].Lines)
+}
+
+// The first row of the active chunk that shows ActiveLine
+func (diff *DiffView) activeRow() int {
+	if diff.IsUnified() && diff.ActiveChunk < len(diff.UnifiedChunks) {
+		for index, row := range diff.UnifiedChunks[diff.ActiveChunk] {
+			if row.PairIndex == diff.ActiveLine {
+				return index
+			}
+		}
+	}
+
+	return diff.ActiveLine
+}
+
+func (diff *DiffView) GoToNextChunk() {
+	diff.ActiveChunk += 1
+	if diff.ActiveChunk >= len(diff.Data.RawChunks) {
+		diff.ActiveChunk = len(diff.Data.RawChunks) - 1
+	}
+	if diff.ActiveChunk < 0 {
+		diff.ActiveChunk = 0
+	}
+
+	diff.scrollToActiveChunk()
+}
+
+func (diff *DiffView) GoToPrevChunk() {
+	diff.ActiveChunk -= 1
+	if diff.ActiveChunk < 0 {
+		diff.ActiveChunk = 0
+	}
+
+	diff.scrollToActiveChunk()
+}
+
+func (diff *DiffView) scrollToActiveChunk() {
+	var top int32 = 0
+	for index := 0; index < diff.ActiveChunk && index < len(diff.Data.NewChunks); index += 1 {
+		top += DIFF_SEPARATOR_HEIGHT + int32(diff.chunkRowCount(index))*23
+	}
+
+	diff.ScrollOffset = -top
+	diff.ActiveLine = 0
+	diff.Selecting = false
 }
 
 func (diff *DiffView) ScrollDown() {
@@ -56,10 +397,77 @@ func (diff *DiffView) ScrollUp() {
 }
 
 func (diff *DiffView) Render(rend *sdl.Renderer, app *App) {
+	diff.renderHeader(rend, app)
+
+	if diff.IsUnified() {
+		diff.renderUnified(rend, app)
+		return
+	}
+
 	diff.renderOld(rend, app)
 	diff.renderNew(rend, app)
 }
 
+// Shows the file on the left and the enabled whitespace options on the right
+func (diff *DiffView) renderHeader(rend *sdl.Renderer, app *App) {
+	renderer.ClipRect(rend, diff.HeaderRect)
+	renderer.DrawRect(rend, diff.HeaderRect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+
+	mainFont := app.Fonts["12"]
+
+	filename := diff.Entry.Filename
+	if diff.Entry.OrigFilename != "" {
+		filename = diff.Entry.OrigFilename + " -> " + diff.Entry.Filename
+	}
+
+	filenameRect := sdl.Rect{
+		X: diff.HeaderRect.X + 10,
+		Y: diff.HeaderRect.Y + (diff.HeaderRect.H-mainFont.Size)/2 + 1,
+		W: mainFont.GetStringWidth(filename),
+		H: mainFont.Size,
+	}
+	renderer.DrawText(rend, &mainFont, filename, &filenameRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+
+	options := diff.optionsText()
+	if options != "" {
+		optionsWidth := mainFont.GetStringWidth(options)
+		optionsRect := sdl.Rect{
+			X: diff.HeaderRect.X + diff.HeaderRect.W - optionsWidth - 10,
+			Y: diff.HeaderRect.Y + (diff.HeaderRect.H-mainFont.Size)/2 + 1,
+			W: optionsWidth,
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, options, &optionsRect, sdl.Color{R: 207, G: 173, B: 16, A: 255})
+	}
+
+	renderer.ClipRect(rend, nil)
+}
+
+func (diff *DiffView) optionsText() string {
+	var ignored []string
+	if diff.Data.Options.IgnoreAllSpace {
+		ignored = append(ignored, "all whitespace")
+	} else if diff.Data.Options.IgnoreSpaceChange {
+		ignored = append(ignored, "whitespace changes")
+	}
+	if diff.Data.Options.IgnoreBlankLines {
+		ignored = append(ignored, "blank lines")
+	}
+	if diff.Data.Options.IgnoreCRAtEOL {
+		ignored = append(ignored, "CR at end of line")
+	}
+
+	var parts []string
+	if len(ignored) > 0 {
+		parts = append(parts, "Ignoring "+strings.Join(ignored, ", "))
+	}
+	if diff.ShowWhitespace {
+		parts = append(parts, "Showing whitespace")
+	}
+
+	return strings.Join(parts, " | ")
+}
+
 func (diff *DiffView) renderOld(rend *sdl.Renderer, app *App) {
 	renderer.ClipRect(rend, diff.OldRect)
 	renderer.DrawRect(rend, diff.OldRect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
@@ -68,7 +476,7 @@ func (diff *DiffView) renderOld(rend *sdl.Renderer, app *App) {
 		return
 	}
 
-	diff.renderChunks(rend, diff.OldRect, diff.Data.OldChunks, app)
+	diff.renderChunks(rend, diff.OldRect, diff.Data.OldChunks, diff.OldTokens, app)
 
 	renderer.ClipRect(rend, nil)
 }
@@ -77,38 +485,47 @@ func (diff *DiffView) renderNew(rend *sdl.Renderer, app *App) {
 	renderer.ClipRect(rend, diff.NewRect)
 	renderer.DrawRect(rend, diff.NewRect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
 
-	message := ""
+	message := diff.placeholderMessage()
+	if message != "" {
+		diff.renderMessage(rend, diff.NewRect, message, app)
+
+		renderer.ClipRect(rend, nil)
+		return
+	}
+
+	diff.renderChunks(rend, diff.NewRect, diff.Data.NewChunks, diff.NewTokens, app)
+
+	renderer.ClipRect(rend, nil)
+}
+
+// The message that is shown instead of the new side of the diff, if any
+func (diff *DiffView) placeholderMessage() string {
 	if diff.Entry.Type == git.GIT_ENTRY_DELETED {
-		message = "File was removed"
+		return "File was removed"
 	} else if len(diff.Data.NewChunks) == 1 && diff.Data.NewChunks[0].BinaryFile {
-		message = "Cannot show diff of binary file"
+		return "Cannot show diff of binary file"
 	}
 
-	if message != "" {
-		renderer.DrawRectTransparent(rend, diff.NewRect, sdl.Color{R: 82, G: 153, B: 19, A: 49})
+	return ""
+}
 
-		font := app.Fonts["24"]
+func (diff *DiffView) renderMessage(rend *sdl.Renderer, rect *sdl.Rect, message string, app *App) {
+	renderer.DrawRectTransparent(rend, rect, sdl.Color{R: 82, G: 153, B: 19, A: 49})
 
-		textWidth := font.GetStringWidth(message)
-		textRect := sdl.Rect{
-			X: diff.NewRect.X + (diff.NewRect.W-textWidth)/2,
-			Y: diff.NewRect.Y + (diff.NewRect.H-font.Size)/2,
-			W: textWidth,
-			H: font.Size,
-		}
+	font := app.Fonts["24"]
 
-		renderer.DrawText(rend, &font, message, &textRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
-
-		renderer.ClipRect(rend, nil)
-		return
+	textWidth := font.GetStringWidth(message)
+	textRect := sdl.Rect{
+		X: rect.X + (rect.W-textWidth)/2,
+		Y: rect.Y + (rect.H-font.Size)/2,
+		W: textWidth,
+		H: font.Size,
 	}
 
-	diff.renderChunks(rend, diff.NewRect, diff.Data.NewChunks, app)
-
-	renderer.ClipRect(rend, nil)
+	renderer.DrawText(rend, &font, message, &textRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
 }
 
-func (diff *DiffView) renderChunks(rend *sdl.Renderer, diffRect *sdl.Rect, chunks []git.GitDiffFile, app *App) {
+func (diff *DiffView) renderChunks(rend *sdl.Renderer, diffRect *sdl.Rect, chunks []git.GitDiffFile, tokens [][][]syntax.Token, app *App) {
 	numbersRect := sdl.Rect{
 		X: diffRect.X,
 		Y: diffRect.Y,
@@ -120,11 +537,11 @@ func (diff *DiffView) renderChunks(rend *sdl.Renderer, diffRect *sdl.Rect, chunk
 	mainFont := app.Fonts["12"]
 
 	var lineHeight int32 = 23
-	var separatorHeight int32 = 12
+	var separatorHeight int32 = DIFF_SEPARATOR_HEIGHT
 
 	chunkStart := diffRect.Y + diff.ScrollOffset
 	lineTop := chunkStart + separatorHeight
-	for _, chunk := range chunks {
+	for chIndex, chunk := range chunks {
 		lineNumber := chunk.StartLine
 
 		separatorRect := sdl.Rect{
@@ -133,9 +550,11 @@ func (diff *DiffView) renderChunks(rend *sdl.Renderer, diffRect *sdl.Rect, chunk
 			W: diffRect.W,
 			H: separatorHeight,
 		}
-		renderer.DrawRect(rend, &separatorRect, sdl.Color{R: 63, G: 63, B: 63, A: 255})
+		diff.renderSeparator(rend, &mainFont, &separatorRect, numbersRect.X+numbersRect.W+10, diff.Data.HiddenLinesAbove(chIndex), chIndex == diff.ActiveChunk && len(chunks) > 1)
+
+		selectionFirst, selectionLast := diff.selectionRange()
 
-		for _, line := range chunk.Lines {
+		for lineIndex, line := range chunk.Lines {
 			if line.Type != git.GIT_LINE_UNMODIFIED && line.Type != git.GIT_LINE_EMPTY {
 				bgRect := sdl.Rect{
 					X: numbersRect.X + numbersRect.W,
@@ -156,6 +575,25 @@ func (diff *DiffView) renderChunks(rend *sdl.Renderer, diffRect *sdl.Rect, chunk
 				}
 
 				renderer.DrawRectTransparent(rend, &lineNumberBgRect, bgColor)
+
+				diff.renderChanges(rend, &mainFont, line, numbersRect.X+numbersRect.W+10, lineTop, lineHeight)
+				if diff.ShowWhitespace {
+					diff.renderWhitespace(rend, &mainFont, chIndex, line, numbersRect.X+numbersRect.W+10, lineTop+(lineHeight-mainFont.Size)/2)
+				}
+			}
+
+			if diff.LineMode && chIndex == diff.ActiveChunk && lineIndex >= selectionFirst && lineIndex <= selectionLast {
+				selectionRect := sdl.Rect{
+					X: diffRect.X,
+					Y: lineTop,
+					W: diffRect.W,
+					H: lineHeight,
+				}
+
+				renderer.DrawRectTransparent(rend, &selectionRect, sdl.Color{R: 38, G: 139, B: 210, A: 49})
+				if lineIndex == diff.ActiveLine {
+					renderer.DrawRectOutline(rend, &selectionRect, sdl.Color{R: 38, G: 139, B: 210, A: 255}, 1)
+				}
 			}
 
 			lineNumberStr := strconv.Itoa(int(lineNumber))
@@ -169,14 +607,7 @@ func (diff *DiffView) renderChunks(rend *sdl.Renderer, diffRect *sdl.Rect, chunk
 			}
 			renderer.DrawText(rend, &mainFont, lineNumberStr, &lineNumberRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
 
-			textWidth := mainFont.GetStringWidth(line.Text)
-			textRect := sdl.Rect{
-				X: numbersRect.X + numbersRect.W + 10,
-				Y: lineTop + (lineHeight-mainFont.Size)/2,
-				W: textWidth,
-				H: mainFont.Size,
-			}
-			renderer.DrawText(rend, &mainFont, line.Text, &textRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+			diff.renderLineText(rend, &mainFont, line.Text, lineTokens(tokens, chIndex, lineIndex), numbersRect.X+numbersRect.W+10, lineTop+(lineHeight-mainFont.Size)/2)
 
 			lineTop += lineHeight
 
@@ -186,6 +617,175 @@ func (diff *DiffView) renderChunks(rend *sdl.Renderer, diffRect *sdl.Rect, chunk
 		chunkStart = lineTop
 		lineTop += separatorHeight
 	}
+
+	if hidden := diff.Data.HiddenLinesBelow(len(chunks)-1, diff.Sources); hidden > 0 {
+		separatorRect := sdl.Rect{X: diffRect.X, Y: chunkStart, W: diffRect.W, H: separatorHeight}
+		diff.renderSeparator(rend, &mainFont, &separatorRect, numbersRect.X+numbersRect.W+10, hidden, false)
+	}
+}
+
+// Active chunks get a highlighted separator, which also says how many unchanged lines it hides
+func (diff *DiffView) renderSeparator(rend *sdl.Renderer, ffont *font.Font, rect *sdl.Rect, textLeft int32, hidden int, active bool) {
+	color := sdl.Color{R: 63, G: 63, B: 63, A: 255}
+	if active {
+		color = sdl.Color{R: 38, G: 139, B: 210, A: 255}
+	}
+	renderer.DrawRect(rend, rect, color)
+
+	if hidden == 0 {
+		return
+	}
+
+	text := strconv.Itoa(hidden) + " hidden lines"
+	if hidden == 1 {
+		text = "1 hidden line"
+	}
+
+	textRect := sdl.Rect{
+		X: textLeft,
+		Y: rect.Y + (rect.H-ffont.Size)/2,
+		W: ffont.GetStringWidth(text),
+		H: ffont.Size,
+	}
+	renderer.DrawText(rend, ffont, text, &textRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+}
+
+// The parts that actually changed get a stronger version of the line's color
+func (diff *DiffView) renderChanges(rend *sdl.Renderer, ffont *font.Font, line git.GitDiffLine, textLeft int32, lineTop int32, lineHeight int32) {
+	color := diff.diffLineTypeToColor(line.Type)
+	color.A = 110
+
+	for _, change := range line.Changes {
+		changeRect := sdl.Rect{
+			X: textLeft + int32(utf8.RuneCountInString(line.Text[:change.Start]))*ffont.CharacterWidth,
+			Y: lineTop + 2,
+			W: int32(utf8.RuneCountInString(line.Text[change.Start:change.End])) * ffont.CharacterWidth,
+			H: lineHeight - 4,
+		}
+		renderer.DrawRectTransparent(rend, &changeRect, color)
+	}
+}
+
+// The text of a line has its tabs expanded to spaces, so the glyphs are placed by walking the line
+// as it is in the patch
+func (diff *DiffView) renderWhitespace(rend *sdl.Renderer, ffont *font.Font, chunk int, line git.GitDiffLine, left int32, top int32) {
+	if line.RawIndex < 0 || chunk >= len(diff.Data.RawChunks) || line.RawIndex >= len(diff.Data.RawChunks[chunk]) {
+		return
+	}
+
+	// The first character is the + or - of the patch line
+	raw := diff.Data.RawChunks[chunk][line.RawIndex]
+	if raw == "" {
+		return
+	}
+
+	column := int32(0)
+	for index, r := range raw[1:] {
+		glyph := ""
+		width := int32(1)
+		if r == ' ' {
+			glyph = "·"
+		} else if r == '\t' {
+			glyph = "→"
+			width = 4
+		} else if r == '\r' && index == len(raw)-2 {
+			glyph = "¤"
+		}
+
+		if glyph != "" {
+			glyphRect := sdl.Rect{X: left + column*ffont.CharacterWidth, Y: top, W: ffont.CharacterWidth, H: ffont.Size}
+			renderer.DrawText(rend, ffont, glyph, &glyphRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+		}
+
+		column += width
+	}
+}
+
+// Draws the text in parts so that every token gets its own color
+func (diff *DiffView) renderLineText(rend *sdl.Renderer, ffont *font.Font, text string, tokens []syntax.Token, left int32, top int32) {
+	pos := 0
+	drawUntil := func(end int, color sdl.Color) {
+		part := text[pos:end]
+		if strings.TrimSpace(part) != "" {
+			partRect := sdl.Rect{
+				X: left + int32(utf8.RuneCountInString(text[:pos]))*ffont.CharacterWidth,
+				Y: top,
+				W: int32(utf8.RuneCountInString(part)) * ffont.CharacterWidth,
+				H: ffont.Size,
+			}
+			renderer.DrawText(rend, ffont, part, &partRect, color)
+		}
+
+		pos = end
+	}
+
+	for _, token := range tokens {
+		drawUntil(token.Start, tokenTypeToColor(syntax.TOKEN_TEXT))
+		drawUntil(token.End, tokenTypeToColor(token.Type))
+	}
+	drawUntil(len(text), tokenTypeToColor(syntax.TOKEN_TEXT))
+}
+
+// Tokenizes the lines of every chunk. Each line starts in the state that the whole file is in at
+// that line, so that a hunk starting inside e.g. a block comment is still highlighted correctly.
+func highlightChunks(language *syntax.Language, chunks []git.GitDiffFile, source string) (result [][][]syntax.Token) {
+	if language == nil {
+		return
+	}
+
+	var states []syntax.State
+	if source != "" {
+		states = language.LineStates(source)
+	}
+
+	for _, chunk := range chunks {
+		chunkTokens := make([][]syntax.Token, len(chunk.Lines))
+
+		// Without the file, the state is only carried from one line of the hunk to the next
+		state := syntax.State{}
+		lineNumber := int(chunk.StartLine)
+		for lineIndex, line := range chunk.Lines {
+			if line.Type == git.GIT_LINE_EMPTY {
+				continue
+			}
+
+			if lineNumber >= 1 && lineNumber <= len(states) {
+				state = states[lineNumber-1]
+			}
+
+			chunkTokens[lineIndex], state = language.TokenizeLine(line.Text, state)
+			lineNumber += 1
+		}
+
+		result = append(result, chunkTokens)
+	}
+
+	return
+}
+
+func tokenTypeToColor(t syntax.TokenType) sdl.Color {
+	switch t {
+	case syntax.TOKEN_KEYWORD:
+		return sdl.Color{R: 211, G: 54, B: 130, A: 255}
+	case syntax.TOKEN_TYPE:
+		return sdl.Color{R: 42, G: 161, B: 152, A: 255}
+	case syntax.TOKEN_CONSTANT:
+		return sdl.Color{R: 108, G: 113, B: 196, A: 255}
+	case syntax.TOKEN_NUMBER:
+		return sdl.Color{R: 203, G: 75, B: 22, A: 255}
+	case syntax.TOKEN_STRING:
+		return sdl.Color{R: 207, G: 173, B: 16, A: 255}
+	case syntax.TOKEN_COMMENT:
+		return sdl.Color{R: 117, G: 113, B: 94, A: 255}
+	case syntax.TOKEN_FUNCTION:
+		return sdl.Color{R: 38, G: 139, B: 210, A: 255}
+	case syntax.TOKEN_KEY:
+		return sdl.Color{R: 38, G: 139, B: 210, A: 255}
+	case syntax.TOKEN_HEADING:
+		return sdl.Color{R: 203, G: 75, B: 22, A: 255}
+	default:
+		return sdl.Color{R: 171, G: 171, B: 171, A: 255}
+	}
 }
 
 func (diff *DiffView) diffLineTypeToColor(t git.GitDiffLineType) sdl.Color {
@@ -202,3 +802,179 @@ func (diff *DiffView) diffLineTypeToColor(t git.GitDiffLineType) sdl.Color {
 		panic("Unreachable")
 	}
 }
+
+type unifiedRow struct {
+	Line      git.GitDiffLine
+	Tokens    []syntax.Token
+	OldNumber int
+	NewNumber int
+
+	// The row of the side by side layout that the line comes from, which is what ActiveLine points at
+	PairIndex int
+}
+
+// Changed lines are listed like in a patch, with all removed lines of a block before the added ones
+func (diff *DiffView) buildUnifiedChunks() (result [][]unifiedRow) {
+	for chIndex := 0; chIndex < len(diff.Data.OldChunks) && chIndex < len(diff.Data.NewChunks); chIndex += 1 {
+		oldChunk := diff.Data.OldChunks[chIndex]
+		newChunk := diff.Data.NewChunks[chIndex]
+
+		oldNumber := int(oldChunk.StartLine)
+		newNumber := int(newChunk.StartLine)
+
+		var rows []unifiedRow
+		var added []unifiedRow
+		for index := 0; index < len(oldChunk.Lines) && index < len(newChunk.Lines); index += 1 {
+			oldLine := oldChunk.Lines[index]
+			newLine := newChunk.Lines[index]
+
+			if oldLine.Type == git.GIT_LINE_UNMODIFIED {
+				rows = append(rows, added...)
+				added = nil
+
+				rows = append(rows, unifiedRow{
+					Line:      newLine,
+					Tokens:    lineTokens(diff.NewTokens, chIndex, index),
+					OldNumber: oldNumber,
+					NewNumber: newNumber,
+					PairIndex: index,
+				})
+
+				oldNumber += 1
+				newNumber += 1
+				continue
+			}
+
+			if oldLine.Type == git.GIT_LINE_REMOVED {
+				rows = append(rows, unifiedRow{Line: oldLine, Tokens: lineTokens(diff.OldTokens, chIndex, index), OldNumber: oldNumber, PairIndex: index})
+				oldNumber += 1
+			}
+
+			if newLine.Type == git.GIT_LINE_NEW {
+				added = append(added, unifiedRow{Line: newLine, Tokens: lineTokens(diff.NewTokens, chIndex, index), NewNumber: newNumber, PairIndex: index})
+				newNumber += 1
+			}
+		}
+
+		result = append(result, append(rows, added...))
+	}
+
+	return
+}
+
+func (diff *DiffView) renderUnified(rend *sdl.Renderer, app *App) {
+	renderer.ClipRect(rend, diff.UnifiedRect)
+	renderer.DrawRect(rend, diff.UnifiedRect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+
+	// A removed file still has its old lines to show, only binary files have nothing
+	if len(diff.Data.NewChunks) == 1 && diff.Data.NewChunks[0].BinaryFile {
+		diff.renderMessage(rend, diff.UnifiedRect, "Cannot show diff of binary file", app)
+
+		renderer.ClipRect(rend, nil)
+		return
+	}
+
+	numbersRect := sdl.Rect{
+		X: diff.UnifiedRect.X,
+		Y: diff.UnifiedRect.Y,
+		W: 80,
+		H: diff.UnifiedRect.H,
+	}
+	renderer.DrawRect(rend, &numbersRect, sdl.Color{R: 30, G: 30, B: 30, A: 255})
+
+	mainFont := app.Fonts["12"]
+
+	var lineHeight int32 = 23
+	var separatorHeight int32 = DIFF_SEPARATOR_HEIGHT
+
+	// Context lines keep the space in front of them from the patch, which lines them up with the text after the markers
+	markerLeft := numbersRect.X + numbersRect.W + 10
+	textLeft := markerLeft + mainFont.CharacterWidth
+
+	selectionFirst, selectionLast := diff.selectionRange()
+
+	chunkStart := diff.UnifiedRect.Y + diff.ScrollOffset
+	lineTop := chunkStart + separatorHeight
+	for chIndex, rows := range diff.UnifiedChunks {
+		separatorRect := sdl.Rect{X: diff.UnifiedRect.X, Y: chunkStart, W: diff.UnifiedRect.W, H: separatorHeight}
+		diff.renderSeparator(rend, &mainFont, &separatorRect, textLeft, diff.Data.HiddenLinesAbove(chIndex), chIndex == diff.ActiveChunk && len(diff.UnifiedChunks) > 1)
+
+		for _, row := range rows {
+			if lineTop+lineHeight < diff.UnifiedRect.Y || lineTop > diff.UnifiedRect.Y+diff.UnifiedRect.H {
+				lineTop += lineHeight
+				continue
+			}
+
+			rowRect := sdl.Rect{X: diff.UnifiedRect.X, Y: lineTop, W: diff.UnifiedRect.W, H: lineHeight}
+
+			left := markerLeft
+			if row.Line.Type != git.GIT_LINE_UNMODIFIED {
+				renderer.DrawRectTransparent(rend, &rowRect, diff.diffLineTypeToColor(row.Line.Type))
+				diff.renderChanges(rend, &mainFont, row.Line, textLeft, lineTop, lineHeight)
+
+				marker := "+"
+				if row.Line.Type == git.GIT_LINE_REMOVED {
+					marker = "-"
+				}
+				markerRect := sdl.Rect{X: markerLeft, Y: lineTop + (lineHeight-mainFont.Size)/2, W: mainFont.CharacterWidth, H: mainFont.Size}
+				renderer.DrawText(rend, &mainFont, marker, &markerRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+
+				if diff.ShowWhitespace {
+					diff.renderWhitespace(rend, &mainFont, chIndex, row.Line, textLeft, lineTop+(lineHeight-mainFont.Size)/2)
+				}
+
+				left = textLeft
+			}
+
+			if diff.LineMode && chIndex == diff.ActiveChunk && row.PairIndex >= selectionFirst && row.PairIndex <= selectionLast {
+				renderer.DrawRectTransparent(rend, &rowRect, sdl.Color{R: 38, G: 139, B: 210, A: 49})
+				if row.PairIndex == diff.ActiveLine {
+					renderer.DrawRectOutline(rend, &rowRect, sdl.Color{R: 38, G: 139, B: 210, A: 255}, 1)
+				}
+			}
+
+			if row.Line.Type != git.GIT_LINE_NEW {
+				diff.renderLineNumber(rend, &mainFont, row.OldNumber, numbersRect.X+40, lineTop, lineHeight)
+			}
+			if row.Line.Type != git.GIT_LINE_REMOVED {
+				diff.renderLineNumber(rend, &mainFont, row.NewNumber, numbersRect.X+80, lineTop, lineHeight)
+			}
+
+			diff.renderLineText(rend, &mainFont, row.Line.Text, row.Tokens, left, lineTop+(lineHeight-mainFont.Size)/2)
+
+			lineTop += lineHeight
+		}
+
+		chunkStart = lineTop
+		lineTop += separatorHeight
+	}
+
+	if hidden := diff.Data.HiddenLinesBelow(len(diff.UnifiedChunks)-1, diff.Sources); hidden > 0 {
+		separatorRect := sdl.Rect{X: diff.UnifiedRect.X, Y: chunkStart, W: diff.UnifiedRect.W, H: separatorHeight}
+		diff.renderSeparator(rend, &mainFont, &separatorRect, textLeft, hidden, false)
+	}
+
+	renderer.ClipRect(rend, nil)
+}
+
+// Draws the number with its right edge 10 pixels left of right
+func (diff *DiffView) renderLineNumber(rend *sdl.Renderer, ffont *font.Font, number int, right int32, lineTop int32, lineHeight int32) {
+	numberStr := strconv.Itoa(number)
+
+	numberWidth := ffont.GetStringWidth(numberStr)
+	numberRect := sdl.Rect{
+		X: right - numberWidth - 10,
+		Y: lineTop + (lineHeight-ffont.Size)/2,
+		W: numberWidth,
+		H: ffont.Size,
+	}
+	renderer.DrawText(rend, ffont, numberStr, &numberRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+}
+
+func lineTokens(tokens [][][]syntax.Token, chunk int, line int) []syntax.Token {
+	if chunk >= len(tokens) || line >= len(tokens[chunk]) {
+		return nil
+	}
+
+	return tokens[chunk][line]
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/DonutLaser/git-client/settings"
+)
+
+func TestToggleLayoutOverridesWindowWidth(t *testing.T) {
+	diff := NewDiffView(800, 600)
+	diff.UnifiedBelowWidth = 1000
+
+	if !diff.IsUnified() {
+		t.Fatal("a window narrower than UnifiedBelowWidth is not unified")
+	}
+
+	diff.ToggleLayout()
+	if diff.IsUnified() || diff.Layout != settings.DIFF_LAYOUT_SPLIT {
+		t.Errorf("toggling a narrow window gives layout %d, want split", diff.Layout)
+	}
+
+	// Going back to what the width picks follows the width again
+	diff.ToggleLayout()
+	if !diff.IsUnified() || diff.Layout != settings.DIFF_LAYOUT_AUTO {
+		t.Errorf("toggling back gives layout %d, want auto", diff.Layout)
+	}
+
+	diff.Resize(1200, 600)
+	if diff.IsUnified() {
+		t.Error("a wide window is unified")
+	}
+
+	diff.ToggleLayout()
+	if !diff.IsUnified() || diff.Layout != settings.DIFF_LAYOUT_UNIFIED {
+		t.Errorf("toggling a wide window gives layout %d, want unified", diff.Layout)
+	}
+}
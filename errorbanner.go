@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type ErrorBanner struct {
+	Rect *sdl.Rect
+
+	Active  bool
+	Title   string
+	Message string
+}
+
+func NewErrorBanner(windowWidth int32, windowHeight int32) (result ErrorBanner) {
+	result.Rect = &sdl.Rect{X: 0, Y: windowHeight - 52, W: windowWidth, H: 52}
+
+	result.Active = false
+
+	return
+}
+
+func (banner *ErrorBanner) Resize(windowWidth int32, windowHeight int32) {
+	banner.Rect.Y = windowHeight - 52
+	banner.Rect.W = windowWidth
+}
+
+func (banner *ErrorBanner) Show(err error) {
+	banner.Title = errorToTitle(err)
+	banner.Message = err.Error()
+
+	banner.Active = true
+}
+
+func (banner *ErrorBanner) Hide() {
+	banner.Active = false
+}
+
+func (banner *ErrorBanner) Render(rend *sdl.Renderer, app *App) {
+	if !banner.Active {
+		return
+	}
+
+	renderer.DrawRect(rend, banner.Rect, sdl.Color{R: 97, G: 23, B: 21, A: 255})
+	renderer.DrawRectOutline(rend, banner.Rect, sdl.Color{R: 169, G: 26, B: 23, A: 255}, 1)
+
+	titleFont := app.Fonts["14"]
+	messageFont := app.Fonts["12"]
+
+	title := banner.Title + " (esc to dismiss)"
+	titleRect := sdl.Rect{
+		X: banner.Rect.X + 10,
+		Y: banner.Rect.Y + 8,
+		W: titleFont.GetStringWidth(title),
+		H: titleFont.Size,
+	}
+	renderer.DrawText(rend, &titleFont, title, &titleRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+
+	messageRect := sdl.Rect{
+		X: banner.Rect.X + 10,
+		Y: titleRect.Y + titleRect.H + 8,
+		W: messageFont.GetStringWidth(banner.Message),
+		H: messageFont.Size,
+	}
+	renderer.DrawText(rend, &messageFont, banner.Message, &messageRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+}
+
+func errorToTitle(err error) string {
+	if errors.Is(err, git.ErrNotARepository) {
+		return "Not a git repository"
+	} else if errors.Is(err, git.ErrMergeConflict) {
+		return "Merge conflict"
+	} else if errors.Is(err, git.ErrNothingToCommit) {
+		return "Nothing to commit"
+	} else if errors.Is(err, git.ErrDirtyWorktree) {
+		return "Local changes would be overwritten"
+	} else if errors.Is(err, git.ErrBranchNotMerged) {
+		return "Branch is not fully merged"
+	}
+
+	var gitErr *git.GitError
+	if errors.As(err, &gitErr) {
+		return "Git command failed"
+	}
+
+	return "Error"
+}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"sort"
+	"strings"
+)
+
+type GitBranch struct {
+	Name     string // Short name, e.g. master or origin/master
+	Remote   bool   // Remote-tracking branch
+	Current  bool
+	Upstream string
+
+	// Only known for local branches with an upstream
+	Ahead        int
+	Behind       int
+	UpstreamGone bool
+
+	LastCommitDate int64
+}
+
+const branchFormat = "--format=%(refname)%00%(refname:short)%00%(HEAD)%00%(upstream:short)%00%(upstream:track,nobracket)%00%(committerdate:unix)%00"
+
+// Lists local branches, followed by remote-tracking branches if includeRemote is set
+func ListBranchDetails(includeRemote bool, pathToRepo string) (result []GitBranch, err error) {
+	command := []string{"for-each-ref", branchFormat, "refs/heads"}
+	if includeRemote {
+		command = append(command, "refs/remotes")
+	}
+
+	output, err := executeGit(command, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return ParseBranchDetails(output), nil
+}
+
+// Sorts branches by name, or with the most recently committed to first. Local branches always
+// come before remote ones.
+func SortBranches(branches []GitBranch, byDate bool) {
+	sort.SliceStable(branches, func(i int, j int) bool {
+		if branches[i].Remote != branches[j].Remote {
+			return !branches[i].Remote
+		}
+
+		if byDate && branches[i].LastCommitDate != branches[j].LastCommitDate {
+			return branches[i].LastCommitDate > branches[j].LastCommitDate
+		}
+
+		return branches[i].Name < branches[j].Name
+	})
+}
+
+func RenameBranch(oldName string, newName string, pathToRepo string) error {
+	_, err := executeGit([]string{"branch", "-m", oldName, newName}, pathToRepo)
+	return err
+}
+
+// Without force git refuses to delete a branch with commits that aren't merged anywhere, see ErrBranchNotMerged
+func DeleteBranch(branchName string, force bool, pathToRepo string) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+
+	_, err := executeGit([]string{"branch", flag, branchName}, pathToRepo)
+	return err
+}
+
+// Whether every commit of the branch is already part of HEAD
+func IsBranchMerged(branchName string, pathToRepo string) bool {
+	_, err := executeGit([]string{"merge-base", "--is-ancestor", branchName, "HEAD"}, pathToRepo)
+	return err == nil
+}
+
+func SetUpstream(branchName string, upstream string, pathToRepo string) error {
+	_, err := executeGit([]string{"branch", "--set-upstream-to=" + upstream, branchName}, pathToRepo)
+	return err
+}
+
+// Creates a local branch named like the remote one without the remote, tracking it, and checks it out
+func CheckoutRemoteBranch(remoteBranch string, pathToRepo string) error {
+	_, err := executeGit([]string{"checkout", "--track", remoteBranch}, pathToRepo)
+	return err
+}
+
+// The name of the local branch that CheckoutRemoteBranch would create
+func LocalBranchName(remoteBranch string) string {
+	_, name, found := strings.Cut(remoteBranch, "/")
+	if !found {
+		return remoteBranch
+	}
+
+	return name
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// What a cherry-pick or revert did before it finished or stopped
+type GitPickResult struct {
+	NewCommits int    // Commits created on top of the previous HEAD
+	Conflicted bool   // Stopped on a conflict, see GetOperationState for how to go on
+	StoppedAt  string // Commit that could not be applied when Conflicted
+}
+
+// Applies the given commits or ranges, e.g. "a1b2c3^..d4e5f6", on top of HEAD
+func CherryPick(revisions []string, pathToRepo string) (GitPickResult, error) {
+	return runPick(append([]string{"cherry-pick"}, revisions...), pathToRepo)
+}
+
+// Creates a commit that undoes each of the given commits or ranges
+func Revert(revisions []string, pathToRepo string) (GitPickResult, error) {
+	return runPick(append([]string{"revert", "--no-edit"}, revisions...), pathToRepo)
+}
+
+// Revisions for every commit from one of the two commits up to and including the other, whichever
+// of them was given first
+func PickRange(first string, last string, pathToRepo string) ([]string, error) {
+	output, err := executeGit([]string{"rev-parse", first, last}, pathToRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := strings.Fields(output)
+	if len(hashes) != 2 {
+		return nil, fmt.Errorf("could not resolve %s and %s", first, last)
+	}
+
+	oldest, newest := hashes[0], hashes[1]
+	if oldest == newest {
+		return []string{oldest}, nil
+	}
+
+	if !IsAncestor(oldest, newest, pathToRepo) {
+		if !IsAncestor(newest, oldest, pathToRepo) {
+			return nil, fmt.Errorf("the range from %s to %s is empty, neither commit is built on the other", first, last)
+		}
+
+		oldest, newest = newest, oldest
+	}
+
+	if _, err := executeGit([]string{"rev-parse", "--verify", "--quiet", oldest + "^"}, pathToRepo); err == nil {
+		return []string{oldest + "^.." + newest}, nil
+	}
+
+	// A root commit has no parent to start the range after, but when it is the only root, all
+	// of the history of the newest commit is the range. A single commit is only walked when asked to.
+	roots, err := executeGit([]string{"rev-list", "--max-parents=0", newest}, pathToRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(roots) != oldest {
+		return nil, fmt.Errorf("%s is a root commit of a history with several roots, start the range at the commit after it", first)
+	}
+
+	return []string{"--do-walk", newest}, nil
+}
+
+// Lists commits from every branch, newest first
+func LogAllBranches(count int, pathToRepo string) (result []GitCommit, err error) {
+	if !hasHead(pathToRepo) {
+		return
+	}
+
+	output, err := executeGit([]string{"log", "--all", "--date-order", logFormat, "-n", strconv.Itoa(count)}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return ParseLog(output), nil
+}
+
+func runPick(command []string, pathToRepo string) (result GitPickResult, err error) {
+	before, err := executeGit([]string{"rev-parse", "HEAD"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	_, pickErr := executeGitWithEnv(command, nonInteractiveEditor, pathToRepo)
+
+	// Whatever was applied before a conflict stays applied
+	count, err := executeGit([]string{"rev-list", "--count", strings.TrimSpace(before) + "..HEAD"}, pathToRepo)
+	if err != nil {
+		return
+	}
+	result.NewCommits, _ = strconv.Atoi(strings.TrimSpace(count))
+
+	if errors.Is(pickErr, ErrMergeConflict) {
+		result.Conflicted = true
+
+		state, stateErr := GetOperationState(pathToRepo)
+		if stateErr == nil {
+			result.StoppedAt = state.StoppedAt
+		}
+	}
+
+	return result, pickErr
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func testHead(t *testing.T, dir string, revision string) string {
+	t.Helper()
+
+	return strings.TrimSpace(runTestGit(t, dir, "rev-parse", revision))
+}
+
+func testSubjects(t *testing.T, dir string, revisions string) []string {
+	t.Helper()
+
+	output := strings.TrimSpace(runTestGit(t, dir, "log", "--format=%s", revisions))
+	if output == "" {
+		return nil
+	}
+
+	return strings.Split(output, "\n")
+}
+
+// main has Root, One, Two, Three; other has Root and Other
+func newTestPickRepo(t *testing.T) (dir string, root string, one string, three string) {
+	dir = newTestRepo(t)
+	commitTestFile(t, dir, "root.txt", "root\n", "Root")
+	root = testHead(t, dir, "HEAD")
+	runTestGit(t, dir, "branch", "other")
+
+	commitTestFile(t, dir, "one.txt", "one\n", "One")
+	one = testHead(t, dir, "HEAD")
+	commitTestFile(t, dir, "two.txt", "two\n", "Two")
+	commitTestFile(t, dir, "three.txt", "three\n", "Three")
+	three = testHead(t, dir, "HEAD")
+
+	runTestGit(t, dir, "checkout", "-q", "other")
+	commitTestFile(t, dir, "other.txt", "other\n", "Other")
+	return
+}
+
+func TestPickRangeInEitherOrder(t *testing.T) {
+	for _, reversed := range []bool{false, true} {
+		dir, _, one, three := newTestPickRepo(t)
+
+		first, last := one, three
+		if reversed {
+			first, last = three, one
+		}
+
+		revisions, err := PickRange(first[:7], last[:7], dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := CherryPick(revisions, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.NewCommits != 3 {
+			t.Errorf("picked %d commits, want 3", result.NewCommits)
+		}
+
+		expectTestLines(t, "subjects", testSubjects(t, dir, "main..HEAD"), "Three", "Two", "One", "Other")
+	}
+}
+
+func TestPickRangeFromRootCommit(t *testing.T) {
+	dir, root, one, _ := newTestPickRepo(t)
+	runTestGit(t, dir, "checkout", "-q", "--orphan", "empty")
+	runTestGit(t, dir, "rm", "-q", "-r", "-f", ".")
+	commitTestFile(t, dir, "start.txt", "start\n", "Start")
+
+	revisions, err := PickRange(one, root, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CherryPick(revisions, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestLines(t, "subjects", testSubjects(t, dir, "HEAD"), "One", "Root", "Start")
+}
+
+func TestPickRangeOfUnrelatedCommits(t *testing.T) {
+	dir, _, one, _ := newTestPickRepo(t)
+
+	if _, err := PickRange(one, "HEAD", dir); err == nil {
+		t.Error("a range between commits on different branches was accepted")
+	}
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCommitMessage = "Subject\n\nFirst paragraph of the body.\n\nSigned-off-by: Someone <someone@example.com>"
+
+func TestRewordCommitKeepsIndex(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "one\n", testCommitMessage)
+
+	message, err := GetLastCommitMessage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message != testCommitMessage {
+		t.Fatalf("got message %q, want %q", message, testCommitMessage)
+	}
+
+	writeTestFile(t, dir, "file.txt", "two\n")
+	runTestGit(t, dir, "add", "file.txt")
+
+	reworded := strings.Replace(message, "First paragraph", "Edited paragraph", 1)
+	if err := RewordCommit(reworded, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if message, _ := GetLastCommitMessage(dir); message != reworded {
+		t.Errorf("got message %q, want %q", message, reworded)
+	}
+	expectTestDiffs(t, dir, []string{"-one", "+two"}, nil)
+}
+
+func TestAmendCommitWithMessage(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "one\n", testCommitMessage)
+
+	writeTestFile(t, dir, "file.txt", "two\n")
+	runTestGit(t, dir, "add", "file.txt")
+
+	if _, err := AmendCommit("", dir); err != nil {
+		t.Fatal(err)
+	}
+	if message, _ := GetLastCommitMessage(dir); message != testCommitMessage {
+		t.Errorf("amending without a message changed it to %q", message)
+	}
+
+	writeTestFile(t, dir, "file.txt", "three\n")
+	runTestGit(t, dir, "add", "file.txt")
+
+	amended := "New subject\n\nNew body."
+	if _, err := AmendCommit(amended, dir); err != nil {
+		t.Fatal(err)
+	}
+	if message, _ := GetLastCommitMessage(dir); message != amended {
+		t.Errorf("got message %q, want %q", message, amended)
+	}
+	expectTestDiffs(t, dir, nil, nil)
+
+	if contents := runTestGit(t, dir, "show", "HEAD:file.txt"); contents != "three\n" {
+		t.Errorf("HEAD has %q, want the staged contents", contents)
+	}
+}
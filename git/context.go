@@ -0,0 +1,140 @@
+package git
+
+import "strings"
+
+// Number of unchanged lines between the chunk and the one before it, or the start of the file
+func (diff *GitDiff) HiddenLinesAbove(chunk int) int {
+	if chunk < 0 || chunk >= len(diff.NewChunks) {
+		return 0
+	}
+
+	above := 0
+	if chunk > 0 {
+		above = diff.NewChunks[chunk-1].lastLine()
+	}
+
+	return nonNegative(diff.NewChunks[chunk].firstLine() - above - 1)
+}
+
+// Number of unchanged lines between the chunk and the next one, or the end of the file
+func (diff *GitDiff) HiddenLinesBelow(chunk int, sources GitDiffSources) int {
+	if chunk < 0 || chunk >= len(diff.NewChunks) {
+		return 0
+	}
+
+	below := len(sourceLines(sources.New)) + 1
+	if chunk < len(diff.NewChunks)-1 {
+		below = diff.NewChunks[chunk+1].firstLine()
+	}
+
+	return nonNegative(below - diff.NewChunks[chunk].lastLine() - 1)
+}
+
+// Moves up to count hidden lines above the chunk into it and returns how many were moved. The
+// lines are only added to the chunk model, RawChunks is left alone so that staging still applies
+// the original hunk.
+func (diff *GitDiff) ExpandAbove(chunk int, count int, sources GitDiffSources) int {
+	if hidden := diff.HiddenLinesAbove(chunk); count > hidden {
+		count = hidden
+	}
+	if count <= 0 || chunk >= len(diff.OldChunks) {
+		return 0
+	}
+
+	oldLines, newLines, ok := diff.contextLines(sources, diff.OldChunks[chunk].firstLine()-count, diff.NewChunks[chunk].firstLine()-count, count)
+	if !ok {
+		return 0
+	}
+
+	diff.OldChunks[chunk].prepend(oldLines)
+	diff.NewChunks[chunk].prepend(newLines)
+
+	return count
+}
+
+// Same as ExpandAbove, but for the lines below the chunk
+func (diff *GitDiff) ExpandBelow(chunk int, count int, sources GitDiffSources) int {
+	if hidden := diff.HiddenLinesBelow(chunk, sources); count > hidden {
+		count = hidden
+	}
+	if count <= 0 || chunk >= len(diff.OldChunks) {
+		return 0
+	}
+
+	oldLines, newLines, ok := diff.contextLines(sources, diff.OldChunks[chunk].lastLine()+1, diff.NewChunks[chunk].lastLine()+1, count)
+	if !ok {
+		return 0
+	}
+
+	diff.OldChunks[chunk].append(oldLines)
+	diff.NewChunks[chunk].append(newLines)
+
+	return count
+}
+
+// Reads count lines of each side starting at the given line numbers. Both sides have to have
+// them, otherwise the sources don't belong to the diff.
+func (diff *GitDiff) contextLines(sources GitDiffSources, oldFirst int, newFirst int, count int) (oldLines []GitDiffLine, newLines []GitDiffLine, ok bool) {
+	oldSource := sourceLines(sources.Old)
+	newSource := sourceLines(sources.New)
+	if oldFirst < 1 || newFirst < 1 || oldFirst+count-1 > len(oldSource) || newFirst+count-1 > len(newSource) {
+		return
+	}
+
+	for index := 0; index < count; index += 1 {
+		oldLines = append(oldLines, contextLine(oldSource[oldFirst-1+index]))
+		newLines = append(newLines, contextLine(newSource[newFirst-1+index]))
+	}
+
+	return oldLines, newLines, true
+}
+
+// Context lines look like the ones parsed from a patch, which keep the space in front of them
+func contextLine(text string) GitDiffLine {
+	return GitDiffLine{
+		Text:     strings.ReplaceAll(" "+text, "\t", "    "),
+		Type:     GIT_LINE_UNMODIFIED,
+		RawIndex: -1,
+	}
+}
+
+func sourceLines(source string) []string {
+	if source == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(source, "\n"), "\n")
+}
+
+// A side without lines starts at the line before the hunk, like in the hunk header
+func (file *GitDiffFile) firstLine() int {
+	if file.EndLine == 0 {
+		return int(file.StartLine) + 1
+	}
+
+	return int(file.StartLine)
+}
+
+func (file *GitDiffFile) lastLine() int {
+	return file.firstLine() + int(file.EndLine) - 1
+}
+
+func (file *GitDiffFile) prepend(lines []GitDiffLine) {
+	file.StartLine = uint32(file.firstLine() - len(lines))
+	file.EndLine += uint32(len(lines))
+	file.Lines = append(lines, file.Lines...)
+}
+
+func (file *GitDiffFile) append(lines []GitDiffLine) {
+	file.StartLine = uint32(file.firstLine())
+	file.EndLine += uint32(len(lines))
+	file.Lines = append(file.Lines, lines...)
+}
+
+func nonNegative(count int) int {
+	if count < 0 {
+		return 0
+	}
+
+	return count
+}
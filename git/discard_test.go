@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscardStagedKeepsLaterEdits(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "Add file")
+	writeTestFile(t, dir, "file.txt", "ONE\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+	runTestGit(t, dir, "add", "file.txt")
+	writeTestFile(t, dir, "file.txt", "ONE\n2\n3\n4\n5\n6\n7\n8\n9\nTEN\n")
+
+	if err := Discard(findTestEntry(t, dir, "file.txt", true), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, nil, []string{"-10", "+TEN"})
+	if contents := readTestFile(t, dir, "file.txt"); contents != "1\n2\n3\n4\n5\n6\n7\n8\n9\nTEN\n" {
+		t.Errorf("worktree file is %q", contents)
+	}
+}
+
+func TestDiscardStagedNewFileRefusesLaterEdits(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "other.txt", "other\n", "Add other file")
+	writeTestFile(t, dir, "new.txt", "a\n")
+	runTestGit(t, dir, "add", "new.txt")
+	writeTestFile(t, dir, "new.txt", "a\nb\n")
+
+	if err := Discard(findTestEntry(t, dir, "new.txt", true), dir); err == nil {
+		t.Fatal("discarding a staged new file that was edited again succeeded")
+	}
+
+	if contents := readTestFile(t, dir, "new.txt"); contents != "a\nb\n" {
+		t.Errorf("worktree file is %q", contents)
+	}
+	expectTestDiffs(t, dir, []string{"+a"}, []string{"+b"})
+}
+
+func TestDiscardStagedNewFile(t *testing.T) {
+	for _, withHead := range []bool{true, false} {
+		dir := newTestRepo(t)
+		if withHead {
+			commitTestFile(t, dir, "other.txt", "other\n", "Add other file")
+		}
+
+		writeTestFile(t, dir, "new.txt", "a\n")
+		runTestGit(t, dir, "add", "new.txt")
+
+		if err := Discard(findTestEntry(t, dir, "new.txt", true), dir); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "new.txt")); !os.IsNotExist(err) {
+			t.Errorf("new.txt still exists (with HEAD: %v)", withHead)
+		}
+		expectTestDiffs(t, dir, nil, nil)
+	}
+}
+
+func TestDiscardStagedRename(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "old.txt", "1\n2\n3\n", "Add file")
+	runTestGit(t, dir, "mv", "old.txt", "new.txt")
+
+	if err := Discard(findTestEntry(t, dir, "new.txt", true), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if contents := readTestFile(t, dir, "old.txt"); contents != "1\n2\n3\n" {
+		t.Errorf("old.txt is %q", contents)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); !os.IsNotExist(err) {
+		t.Error("new.txt still exists")
+	}
+	expectTestDiffs(t, dir, nil, nil)
+}
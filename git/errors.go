@@ -0,0 +1,84 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNotARepository  = errors.New("not a git repository")
+	ErrMergeConflict   = errors.New("merge conflict")
+	ErrNothingToCommit = errors.New("nothing to commit")
+	ErrDirtyWorktree   = errors.New("local changes would be overwritten")
+	ErrBranchNotMerged = errors.New("branch is not fully merged")
+)
+
+type GitError struct {
+	Command  []string
+	ExitCode int
+	Stderr   string
+	Kind     error
+}
+
+func (err *GitError) Error() string {
+	message := firstNonEmptyLine(err.Stderr)
+	if message == "" && err.Kind != nil {
+		message = err.Kind.Error()
+	}
+
+	return fmt.Sprintf("git %s failed (exit code %d): %s", strings.Join(err.Command, " "), err.ExitCode, message)
+}
+
+// Makes errors.Is(err, git.ErrMergeConflict) and friends work
+func (err *GitError) Unwrap() error {
+	return err.Kind
+}
+
+func classifyError(stdout string, stderr string) error {
+	combined := strings.ToLower(stdout + "\n" + stderr)
+
+	// Only whole messages count, file names in the output can contain any of these words. Merges
+	// print their CONFLICT lines to stdout, everything else goes to stderr.
+	conflict := hasLinePrefix(stdout+"\n"+stderr, "CONFLICT (") || strings.Contains(stderr, "could not apply") || strings.Contains(stderr, "Could not apply") || strings.Contains(stderr, "Merge conflict in")
+	nothingToCommit := hasLinePrefix(stdout+"\n"+stderr, "nothing to commit", "nothing added to commit", "no changes added to commit")
+
+	if strings.Contains(combined, "not a git repository") {
+		return ErrNotARepository
+	} else if nothingToCommit && !conflict {
+		// A stash pop that conflicts also says that there are no changes added to commit
+		return ErrNothingToCommit
+	} else if conflict {
+		return ErrMergeConflict
+	} else if strings.Contains(combined, "would be overwritten by") {
+		return ErrDirtyWorktree
+	} else if strings.Contains(combined, "is not fully merged") {
+		return ErrBranchNotMerged
+	}
+
+	return nil
+}
+
+func hasLinePrefix(text string, prefixes ...string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(strings.TrimSpace(line), prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func firstNonEmptyLine(text string) string {
+	// Progress output like rebase's redraws lines with \r
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" {
+			return trimmed
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCommitWithNothingStagedIsNotAConflict(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "one\n", "First")
+	writeTestFile(t, dir, "conflictview.go", "package main\n")
+
+	_, err := Commit("Nothing", dir)
+	if !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("got %v", err)
+	}
+	if errors.Is(err, ErrMergeConflict) {
+		t.Error("classified as a merge conflict")
+	}
+}
+
+// Two branches that both change the only line of file.txt, main is checked out
+func newConflictingTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "base\n", "Base")
+	runTestGit(t, dir, "checkout", "-q", "-b", "topic")
+	commitTestFile(t, dir, "file.txt", "topic\n", "Topic")
+	runTestGit(t, dir, "checkout", "-q", "main")
+	commitTestFile(t, dir, "file.txt", "main\n", "Main")
+
+	return dir
+}
+
+func TestMergeConflictIsClassified(t *testing.T) {
+	dir := newConflictingTestRepo(t)
+
+	_, err := executeGit([]string{"merge", "topic"}, dir)
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Errorf("merge: got %v", err)
+	}
+}
+
+func TestRebaseConflictIsClassified(t *testing.T) {
+	dir := newConflictingTestRepo(t)
+
+	_, err := executeGit([]string{"rebase", "topic"}, dir)
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Errorf("rebase: got %v", err)
+	}
+}
+
+func TestStashPopConflictIsClassified(t *testing.T) {
+	dir := newConflictingTestRepo(t)
+	writeTestFile(t, dir, "file.txt", "stashed\n")
+	runTestGit(t, dir, "stash", "-q")
+	runTestGit(t, dir, "checkout", "-q", "topic")
+
+	_, err := executeGit([]string{"stash", "pop"}, dir)
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Errorf("stash pop: got %v", err)
+	}
+}
+
+func TestErrorsAreClassifiedWithTranslatedGit(t *testing.T) {
+	// LANGUAGE picks the translation with any locale other than C
+	t.Setenv("LANGUAGE", "de")
+	t.Setenv("LANG", "C.UTF-8")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+
+	// Without a German translation of git, its messages are English anyway
+	output, _ := exec.Command("git", "-C", t.TempDir(), "status").CombinedOutput()
+	if strings.Contains(string(output), "not a git repository") {
+		t.Skip("git has no German translation here")
+	}
+
+	if _, err := executeGit([]string{"status"}, t.TempDir()); !errors.Is(err, ErrNotARepository) {
+		t.Errorf("status outside of a repository: got %v", err)
+	}
+
+	dir := newConflictingTestRepo(t)
+	if _, err := Commit("Nothing", dir); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("commit with nothing staged: got %v", err)
+	}
+
+	if _, err := executeGit([]string{"merge", "topic"}, dir); !errors.Is(err, ErrMergeConflict) {
+		t.Errorf("conflicting merge: got %v", err)
+	}
+}
@@ -2,11 +2,20 @@ package git
 
 import (
 	"bytes"
+	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// Fields of a commit separated by NUL, see ParseLog
+const logFormat = "--format=%H%x00%h%x00%P%x00%an%x00%ae%x00%at%x00%D%x00%s%x00"
+
 type GitStatusEntryType uint16
+type GitFileState uint8
 type GitDiffLineType uint8
 
 const (
@@ -14,6 +23,23 @@ const (
 	GIT_ENTRY_NEW_UNSTAGED
 	GIT_ENTRY_NEW
 	GIT_ENTRY_DELETED
+	GIT_ENTRY_RENAMED
+	GIT_ENTRY_COPIED
+	GIT_ENTRY_TYPE_CHANGED
+	GIT_ENTRY_CONFLICTED
+)
+
+// State of a file in either the index or the worktree column of `git status`
+const (
+	GIT_STATE_UNMODIFIED GitFileState = iota
+	GIT_STATE_MODIFIED
+	GIT_STATE_TYPE_CHANGED
+	GIT_STATE_ADDED
+	GIT_STATE_DELETED
+	GIT_STATE_RENAMED
+	GIT_STATE_COPIED
+	GIT_STATE_UPDATED_UNMERGED
+	GIT_STATE_UNTRACKED
 )
 
 const (
@@ -24,14 +50,89 @@ const (
 )
 
 type GitStatusEntry struct {
-	Filename string
-	Type     GitStatusEntryType
-	Selected bool
+	Filename     string
+	OrigFilename string // Only set for renames and copies
+	Type         GitStatusEntryType
+
+	IndexState    GitFileState
+	WorktreeState GitFileState
+
+	// Octal file modes, e.g. 100644, and 000000 where the file doesn't exist. A conflicted file has
+	// no single mode in the index, HeadMode is the one of our side (stage 2) and IndexMode the one
+	// of their side (stage 3).
+	HeadMode     string
+	IndexMode    string
+	WorktreeMode string
+	Submodule    GitSubmoduleState
+
+	// Whether this entry describes the index side (staged) or the worktree side (unstaged) of the file
+	Staged bool
+}
+
+type GitSubmoduleState struct {
+	IsSubmodule       bool
+	CommitChanged     bool
+	HasTrackedChanges bool
+	HasUntrackedFiles bool
 }
 
 type GitDiff struct {
 	OldChunks []GitDiffFile
 	NewChunks []GitDiffFile
+
+	// The unmodified patch text, kept around so that parts of it can be applied with `git apply`
+	Header    []string
+	RawChunks [][]string
+
+	// The options the diff was made with, applying it has to ignore the same whitespace
+	Options GitDiffOptions
+}
+
+// Zero values leave the choice to git
+type GitDiffOptions struct {
+	// Number of unchanged lines shown around each hunk, passed as -U
+	ContextLines int
+
+	IgnoreSpaceChange bool
+	IgnoreAllSpace    bool
+	IgnoreBlankLines  bool
+	IgnoreCRAtEOL     bool
+}
+
+func (options GitDiffOptions) args() (result []string) {
+	if options.ContextLines > 0 {
+		result = append(result, "-U"+strconv.Itoa(options.ContextLines))
+	}
+	if options.IgnoreSpaceChange {
+		result = append(result, "--ignore-space-change")
+	}
+	if options.IgnoreAllSpace {
+		result = append(result, "--ignore-all-space")
+	}
+	if options.IgnoreBlankLines {
+		result = append(result, "--ignore-blank-lines")
+	}
+	if options.IgnoreCRAtEOL {
+		result = append(result, "--ignore-cr-at-eol")
+	}
+
+	return
+}
+
+// Context lines of a diff that ignores whitespace can differ from the file the patch is applied to
+func (options GitDiffOptions) applyArgs() (result []string) {
+	if options.IgnoreSpaceChange || options.IgnoreAllSpace || options.IgnoreCRAtEOL {
+		result = append(result, "--ignore-whitespace")
+	}
+
+	return
+}
+
+func parseDiffWithOptions(output string, options GitDiffOptions) (result GitDiff) {
+	result = ParseDiff(output)
+	result.Options = options
+
+	return
 }
 
 type GitDiffFile struct {
@@ -44,136 +145,624 @@ type GitDiffFile struct {
 type GitDiffLine struct {
 	Text string
 	Type GitDiffLineType
+
+	// Index of the line in GitDiff.RawChunks, -1 for GIT_LINE_EMPTY
+	RawIndex int
+
+	// Parts of the text that differ from the line it is paired with, see GitDiff.MarkChangedRanges
+	Changes []GitTextRange
+}
+
+type GitCommit struct {
+	Hash        string
+	ShortHash   string
+	Parents     []string
+	AuthorName  string
+	AuthorEmail string
+	AuthorDate  time.Time
+	Refs        []string
+	Subject     string
+}
+
+type GitBlame struct {
+	Filename string
+	Revision string // Empty when blaming the working tree
+	Lines    []GitBlameLine
+	Commits  map[string]GitBlameCommit
+}
+
+type GitBlameLine struct {
+	Hash      string
+	OrigLine  uint32
+	FinalLine uint32
+	Filename  string // Path of the file in the commit that introduced the line
+	Text      string
+}
+
+type GitBlameCommit struct {
+	Hash             string
+	AuthorName       string
+	AuthorEmail      string
+	AuthorDate       time.Time
+	Summary          string
+	PreviousHash     string
+	PreviousFilename string
+	Boundary         bool
+}
+
+// Consecutive lines of a blame that come from the same commit
+type GitBlameBlock struct {
+	Hash      string
+	StartLine int
+	LineCount int
+}
+
+type GitConflictResolution uint8
+
+const (
+	GIT_RESOLUTION_NONE GitConflictResolution = iota
+	GIT_RESOLUTION_OURS
+	GIT_RESOLUTION_THEIRS
+	GIT_RESOLUTION_BOTH
+)
+
+// A file with conflict markers, split into the parts both sides agree on and the conflicts between them
+type GitConflictFile struct {
+	Filename string
+	Sections []GitConflictSection
+}
+
+// All lines keep their line endings so that the file can be written back exactly as it was
+type GitConflictSection struct {
+	IsConflict bool
+	Common     []string // Only for sections outside of a conflict
+
+	Ours        []string
+	Base        []string // Only with merge.conflictStyle diff3 or zdiff3
+	Theirs      []string
+	OursLabel   string
+	TheirsLabel string
+	Raw         []string // The whole conflict including the markers
+	Resolution  GitConflictResolution
 }
 
 type GitStashEntry struct {
 	BranchName string
-	Index      string
+	Index      string // e.g. stash@{0}
+	Hash       string
+	Message    string
+	Date       time.Time
+}
+
+func Status(pathToRepo string) (result []GitStatusEntry, err error) {
+	output, err := executeGit([]string{"status", "--porcelain=v2", "-z", "-u"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return ParseStatus(output), nil
 }
 
-func Status(pathToRepo string) (result []GitStatusEntry) {
-	output := executeGit([]string{"status", "--porcelain", "-u"}, pathToRepo)
-	return ParseStatus(output)
+// Splits the entries returned by Status into the changes that are in the index and the changes
+// that are only in the worktree. A file that is modified in both shows up in both lists.
+func SplitStagedEntries(entries []GitStatusEntry) (staged []GitStatusEntry, unstaged []GitStatusEntry) {
+	for _, entry := range entries {
+		if entry.IndexState != GIT_STATE_UNMODIFIED && entry.IndexState != GIT_STATE_UNTRACKED && entry.Type != GIT_ENTRY_CONFLICTED {
+			stagedEntry := entry
+			stagedEntry.Staged = true
+			stagedEntry.Type = fileStatesToChangeType(entry.IndexState, GIT_STATE_UNMODIFIED)
+
+			staged = append(staged, stagedEntry)
+		}
+
+		if entry.WorktreeState != GIT_STATE_UNMODIFIED || entry.Type == GIT_ENTRY_CONFLICTED {
+			unstagedEntry := entry
+			unstagedEntry.Staged = false
+			unstagedEntry.OrigFilename = ""
+			if entry.Type != GIT_ENTRY_CONFLICTED && entry.Type != GIT_ENTRY_NEW_UNSTAGED {
+				unstagedEntry.Type = fileStatesToChangeType(GIT_STATE_UNMODIFIED, entry.WorktreeState)
+			}
+
+			unstaged = append(unstaged, unstagedEntry)
+		}
+	}
+
+	return
 }
 
-func Discard(filename string, pathToRepo string) {
-	executeGit([]string{"restore", filename}, pathToRepo)
+func Stage(entry GitStatusEntry, pathToRepo string) error {
+	_, err := executeGit([]string{"add", "--", entry.Filename}, pathToRepo)
+	return err
 }
 
-func DiscardAll(pathToRepo string) {
-	executeGit([]string{"reset", "--hard"}, pathToRepo)
-	executeGit([]string{"clean", "-fxd"}, pathToRepo)
+func Unstage(entry GitStatusEntry, pathToRepo string) error {
+	paths := []string{entry.Filename}
+	if entry.OrigFilename != "" {
+		paths = append(paths, entry.OrigFilename)
+	}
+
+	// `git restore --staged` needs HEAD to restore from, which doesn't exist before the first commit
+	if !hasHead(pathToRepo) {
+		_, err := executeGit(append([]string{"rm", "--cached", "-q", "--"}, paths...), pathToRepo)
+		return err
+	}
+
+	_, err := executeGit(append([]string{"restore", "--staged", "--"}, paths...), pathToRepo)
+	return err
 }
 
-func Stash(pathToRepo string) {
-	executeGit([]string{"stash", "-u"}, pathToRepo)
+func StageAll(pathToRepo string) error {
+	_, err := executeGit([]string{"add", "-A"}, pathToRepo)
+	return err
 }
 
-func SwitchToBranch(branchName string, pathToRepo string) {
-	executeGit([]string{"checkout", branchName}, pathToRepo)
+func UnstageAll(pathToRepo string) error {
+	if !hasHead(pathToRepo) {
+		_, err := executeGit([]string{"rm", "--cached", "-r", "-q", "."}, pathToRepo)
+		return err
+	}
+
+	_, err := executeGit([]string{"restore", "--staged", "."}, pathToRepo)
+	return err
 }
 
-func ListBranches(pathToRepo string) (result []string) {
-	output := executeGit([]string{"branch", "-l", "--format='%(refname:short)'"}, pathToRepo)
-	return ParseBranches(output)
+func Discard(entry GitStatusEntry, pathToRepo string) error {
+	if !entry.Staged {
+		_, err := executeGit([]string{"restore", "--", entry.Filename}, pathToRepo)
+		return err
+	}
+
+	paths := []string{entry.Filename}
+	if entry.OrigFilename != "" {
+		paths = append(paths, entry.OrigFilename)
+	}
+
+	// Only the staged change is taken back out, edits made to the file since it was staged stay
+	patch, err := executeGit(append([]string{"diff", "--cached", "--binary", "-M", "--"}, paths...), pathToRepo)
+	if err != nil || patch == "" {
+		return err
+	}
+
+	return discardPatch(entry, patch, GitDiffOptions{}, pathToRepo)
 }
 
-func ListStash(pathToRepo string) (result []GitStashEntry) {
-	output := executeGit([]string{"stash", "list"}, pathToRepo)
-	return ParseStashList(output)
+func DiscardAll(pathToRepo string) error {
+	_, err := executeGit([]string{"reset", "--hard"}, pathToRepo)
+	if err != nil {
+		return err
+	}
+
+	_, err = executeGit([]string{"clean", "-fxd"}, pathToRepo)
+	return err
 }
 
-func DoesBranchHaveStash(branchName string, stash []GitStashEntry) bool {
-	for _, entry := range stash {
-		if entry.BranchName == branchName {
-			return true
+// Splits off the entries that have merge conflicts from the rest
+func SplitConflictedEntries(entries []GitStatusEntry) (conflicted []GitStatusEntry, rest []GitStatusEntry) {
+	for _, entry := range entries {
+		if entry.Type == GIT_ENTRY_CONFLICTED {
+			conflicted = append(conflicted, entry)
+		} else {
+			rest = append(rest, entry)
 		}
 	}
 
-	return false
+	return
 }
 
-func GetStashIndex(branchName string, stash []GitStashEntry) string {
+func Stash(pathToRepo string) error {
+	_, err := executeGit([]string{"stash", "-u"}, pathToRepo)
+	return err
+}
+
+func SwitchToBranch(branchName string, pathToRepo string) error {
+	_, err := executeGit([]string{"checkout", branchName}, pathToRepo)
+	return err
+}
+
+func ListBranches(pathToRepo string) (result []string, err error) {
+	output, err := executeGit([]string{"branch", "-l", "--format='%(refname:short)'"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return ParseBranches(output), nil
+}
+
+func ListStash(pathToRepo string) (result []GitStashEntry, err error) {
+	output, err := executeGit([]string{"stash", "list", "--format=%gd%x00%H%x00%ct%x00%gs%x00"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return ParseStashList(output), nil
+}
+
+func DoesBranchHaveStash(branchName string, stash []GitStashEntry) bool {
 	for _, entry := range stash {
 		if entry.BranchName == branchName {
-			return entry.Index
+			return true
 		}
 	}
 
-	return ""
+	return false
 }
 
-func GetCurrentBranch(pathToRepo string) string {
-	output := executeGit([]string{"branch", "--show-current"}, pathToRepo)
-	return strings.TrimSpace(output)
+func GetCurrentBranch(pathToRepo string) (string, error) {
+	output, err := executeGit([]string{"branch", "--show-current"}, pathToRepo)
+	return strings.TrimSpace(output), err
 }
 
-func DiffEntry(entry GitStatusEntry, pathToRepo string) (result GitDiff) {
+func DiffEntry(entry GitStatusEntry, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	if entry.Staged {
+		switch entry.Type {
+		case GIT_ENTRY_RENAMED:
+			fallthrough
+		case GIT_ENTRY_COPIED:
+			return diffRenamed(entry.OrigFilename, entry.Filename, options, pathToRepo)
+		default:
+			return diffStaged(entry.Filename, options, pathToRepo)
+		}
+	}
+
 	switch entry.Type {
 	case GIT_ENTRY_NEW_UNSTAGED:
-		fallthrough
-	case GIT_ENTRY_NEW:
-		return diffNew(entry.Filename, pathToRepo)
-	case GIT_ENTRY_MODIFIED:
-		fallthrough
-	case GIT_ENTRY_DELETED:
-		return diffModified(entry.Filename, pathToRepo)
+		return diffNew(entry.Filename, options, pathToRepo)
+	case GIT_ENTRY_CONFLICTED:
+		return diffConflicted(entry.Filename, options, pathToRepo)
 	default:
-		panic("Unreachable")
+		return diffUnstaged(entry.Filename, options, pathToRepo)
 	}
 }
 
-func Commit(entries []GitStatusEntry, message string, pathToRepo string) (result []GitStatusEntry) {
-	fileNames := make([]string, 0)
+// Commits whatever is currently in the index
+func Commit(message string, pathToRepo string) (result []GitStatusEntry, err error) {
+	// Read from stdin so that the message can have a body
+	_, err = executeGitWithInput([]string{"commit", "-F", "-"}, message, pathToRepo)
+	if err != nil {
+		return
+	}
 
-	for _, entry := range entries {
-		if entry.Selected {
-			if entry.Type == GIT_ENTRY_NEW_UNSTAGED {
-				executeGit([]string{"add", entry.Filename}, pathToRepo)
-			}
+	return Status(pathToRepo)
+}
+
+// The file set in commit.template without its comment lines, or "" if there is none
+func GetCommitTemplate(pathToRepo string) string {
+	templatePath := GetConfig("commit.template", pathToRepo)
+	if templatePath == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(templatePath, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			templatePath = filepath.Join(home, templatePath[2:])
+		}
+	} else if !filepath.IsAbs(templatePath) {
+		templatePath = filepath.Join(pathToRepo, templatePath)
+	}
+
+	contents, err := os.ReadFile(templatePath)
+	if err != nil {
+		return ""
+	}
 
-			fileNames = append(fileNames, entry.Filename)
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
 		}
 	}
 
-	executeGit([]string{"commit", "-o", strings.Join(fileNames, " "), "-m", message}, pathToRepo)
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func UndoLastCommit(pathToRepo string) (result []GitStatusEntry, err error) {
+	_, err = executeGit([]string{"reset", "--soft", "HEAD~"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
 	return Status(pathToRepo)
 }
 
-func UndoLastCommit(pathToRepo string) (result []GitStatusEntry) {
-	executeGit([]string{"reset", "--soft", "HEAD~"}, pathToRepo)
+// Folds whatever is currently in the index into HEAD. HEAD's message is kept if message is empty,
+// otherwise it is replaced by message.
+func AmendCommit(message string, pathToRepo string) (result []GitStatusEntry, err error) {
+	if message == "" {
+		_, err = executeGit([]string{"commit", "--amend", "--no-edit"}, pathToRepo)
+	} else {
+		_, err = executeGitWithInput([]string{"commit", "--amend", "-F", "-"}, message, pathToRepo)
+	}
+
+	if err != nil {
+		return
+	}
+
 	return Status(pathToRepo)
 }
 
-func ApplyStash(index string, pathToRepo string) (result []GitStatusEntry) {
-	executeGit([]string{"stash", "pop", index}, pathToRepo)
+// Replaces HEAD's message and leaves whatever is in the index out of it
+func RewordCommit(message string, pathToRepo string) error {
+	_, err := executeGitWithInput([]string{"commit", "--amend", "--only", "-F", "-"}, message, pathToRepo)
+	return err
+}
+
+// HEAD's subject, body and trailers
+func GetLastCommitMessage(pathToRepo string) (string, error) {
+	output, err := executeGit([]string{"log", "-1", "--format=%B"}, pathToRepo)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(output, "\n"), nil
+}
+
+// Whether HEAD is already part of the current branch's upstream, so rewriting it needs a force push
+func IsHeadPushed(pathToRepo string) bool {
+	_, err := executeGit([]string{"merge-base", "--is-ancestor", "HEAD", "@{u}"}, pathToRepo)
+	return err == nil
+}
+
+func PopStash(index string, pathToRepo string) (result []GitStatusEntry, err error) {
+	_, err = executeGit([]string{"stash", "pop", index}, pathToRepo)
+	if err != nil {
+		return
+	}
+
 	return Status(pathToRepo)
 }
 
-func DeleteStash(index string, pathToRepo string) {
-	executeGit([]string{"stash", "drop", index}, pathToRepo)
+func DeleteStash(index string, pathToRepo string) error {
+	_, err := executeGit([]string{"stash", "drop", index}, pathToRepo)
+	return err
+}
+
+// Reads a conflicted file from the working tree. A file that was deleted on one side may not
+// exist, which results in a file without sections rather than an error.
+func ReadConflictFile(filename string, pathToRepo string) (result GitConflictFile, err error) {
+	result.Filename = filename
+
+	contents, err := os.ReadFile(filepath.Join(pathToRepo, filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+
+		return
+	}
+
+	result.Sections = ParseConflicts(string(contents))
+
+	return
 }
 
-func CreateRepository(pathToRepo string) {
-	executeGit([]string{"init"}, pathToRepo)
+// Writes the file back with all resolved conflicts replaced by the chosen side, unresolved
+// conflicts keep their markers
+func WriteConflictFile(file GitConflictFile, pathToRepo string) error {
+	return os.WriteFile(filepath.Join(pathToRepo, file.Filename), []byte(file.Contents()), 0644)
 }
 
-func CreateBranch(branchName string, pathToRepo string) {
-	executeGit([]string{"checkout", "-b", branchName}, pathToRepo)
+func (file *GitConflictFile) Contents() string {
+	var builder strings.Builder
+	for _, section := range file.Sections {
+		for _, line := range section.ResolvedLines() {
+			builder.WriteString(line)
+		}
+	}
+
+	return builder.String()
 }
 
-func diffNew(filename string, pathToRepo string) (result GitDiff) {
-	output := executeGit([]string{"diff", "--no-index", "/dev/null", filename}, pathToRepo)
-	return ParseDiff(output)
+func (file *GitConflictFile) ConflictCount() (result int) {
+	for _, section := range file.Sections {
+		if section.IsConflict {
+			result += 1
+		}
+	}
+
+	return
 }
 
-func diffModified(filename string, pathToRepo string) (result GitDiff) {
-	output := executeGit([]string{"diff", "HEAD", "--", filename}, pathToRepo)
-	return ParseDiff(output)
+func (file *GitConflictFile) UnresolvedCount() (result int) {
+	for _, section := range file.Sections {
+		if section.IsConflict && section.Resolution == GIT_RESOLUTION_NONE {
+			result += 1
+		}
+	}
 
+	return
 }
 
-func executeGit(command []string, cwd string) string {
+// The lines this section contributes to the merged file
+func (section *GitConflictSection) ResolvedLines() []string {
+	if !section.IsConflict {
+		return section.Common
+	}
+
+	switch section.Resolution {
+	case GIT_RESOLUTION_OURS:
+		return section.Ours
+	case GIT_RESOLUTION_THEIRS:
+		return section.Theirs
+	case GIT_RESOLUTION_BOTH:
+		return joinConflictSides(section.Ours, section.Theirs)
+	default:
+		return section.Raw
+	}
+}
+
+func CreateRepository(pathToRepo string) error {
+	_, err := executeGit([]string{"init"}, pathToRepo)
+	return err
+}
+
+func CreateBranch(branchName string, pathToRepo string) error {
+	_, err := executeGit([]string{"checkout", "-b", branchName}, pathToRepo)
+	return err
+}
+
+// Lists the files changed by a commit. Merge commits are compared with their first parent.
+func CommitFiles(commit GitCommit, pathToRepo string) (result []GitStatusEntry, err error) {
+	var output string
+	if len(commit.Parents) > 0 {
+		output, err = executeGit([]string{"diff", "-M", "--name-status", "-z", commit.Parents[0], commit.Hash}, pathToRepo)
+	} else {
+		output, err = executeGit([]string{"diff-tree", "--root", "--no-commit-id", "-r", "-M", "--name-status", "-z", commit.Hash}, pathToRepo)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return ParseNameStatus(output), nil
+}
+
+func DiffCommitEntry(commit GitCommit, entry GitStatusEntry, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	paths := []string{entry.Filename}
+	if entry.OrigFilename != "" {
+		paths = append(paths, entry.OrigFilename)
+	}
+
+	var command []string
+	if len(commit.Parents) > 0 {
+		command = append([]string{"diff", "-M"}, options.args()...)
+		command = append(command, commit.Parents[0], commit.Hash)
+	} else {
+		command = append([]string{"show", "--format=", "-M"}, options.args()...)
+		command = append(command, commit.Hash)
+	}
+
+	command = append(append(command, "--"), paths...)
+	output, err := executeGit(command, pathToRepo)
+
+	if err != nil {
+		return
+	}
+
+	return parseDiffWithOptions(output, options), nil
+}
+
+// Blames the file as it is in the given revision, or in the working tree if revision is empty
+func Blame(filename string, revision string, pathToRepo string) (result GitBlame, err error) {
+	command := []string{"blame", "--porcelain"}
+	if revision != "" {
+		command = append(command, revision)
+	}
+	command = append(command, "--", filename)
+
+	output, err := executeGit(command, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	result = ParseBlame(output)
+	result.Filename = filename
+	result.Revision = revision
+
+	return
+}
+
+func GroupBlameLines(lines []GitBlameLine) (result []GitBlameBlock) {
+	for index, line := range lines {
+		last := len(result) - 1
+		if last >= 0 && result[last].Hash == line.Hash {
+			result[last].LineCount += 1
+			continue
+		}
+
+		result = append(result, GitBlameBlock{Hash: line.Hash, StartLine: index, LineCount: 1})
+	}
+
+	return
+}
+
+func IsUncommittedHash(hash string) bool {
+	return strings.Trim(hash, "0") == ""
+}
+
+func diffNew(filename string, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	output, err := executeGit(append(append([]string{"diff", "--no-index"}, options.args()...), "/dev/null", filename), pathToRepo)
+
+	// `git diff --no-index` exits with 1 when the files differ, which they always do here
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	return parseDiffWithOptions(output, options), nil
+}
+
+func diffUnstaged(filename string, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	output, err := executeGit(append(append([]string{"diff"}, options.args()...), "--", filename), pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return parseDiffWithOptions(output, options), nil
+}
+
+func diffStaged(filename string, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	output, err := executeGit(append(append([]string{"diff", "--cached"}, options.args()...), "--", filename), pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return parseDiffWithOptions(output, options), nil
+}
+
+// The index holds several stages for a conflicted file, so compare the worktree with HEAD instead
+func diffConflicted(filename string, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	output, err := executeGit(append(append([]string{"diff", "HEAD"}, options.args()...), "--", filename), pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return parseDiffWithOptions(output, options), nil
+}
+
+func diffRenamed(origFilename string, filename string, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	output, err := executeGit(append(append([]string{"diff", "--cached", "-M"}, options.args()...), "--", origFilename, filename), pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return parseDiffWithOptions(output, options), nil
+}
+
+// Taking both sides must not glue the last line of ours to the first line of theirs when ours
+// ends the file without a newline
+func joinConflictSides(ours []string, theirs []string) (result []string) {
+	result = append(result, ours...)
+	if len(result) > 0 && len(theirs) > 0 && !strings.HasSuffix(result[len(result)-1], "\n") {
+		result[len(result)-1] += "\n"
+	}
+
+	return append(result, theirs...)
+}
+
+func hasHead(pathToRepo string) bool {
+	_, err := executeGit([]string{"rev-parse", "--verify", "-q", "HEAD"}, pathToRepo)
+	return err == nil
+}
+
+func executeGit(command []string, cwd string) (string, error) {
+	return executeGitWithInput(command, "", cwd)
+}
+
+func executeGitWithInput(command []string, input string, cwd string) (string, error) {
+	return runGit(command, input, nil, cwd)
+}
+
+// Runs git with extra environment variables on top of the current environment
+func executeGitWithEnv(command []string, env []string, cwd string) (string, error) {
+	return runGit(command, "", env, cwd)
+}
+
+func runGit(command []string, input string, env []string, cwd string) (string, error) {
 	var result bytes.Buffer
 	var er bytes.Buffer
 
@@ -181,11 +770,48 @@ func executeGit(command []string, cwd string) string {
 	cmd.Stdout = &result
 	cmd.Stderr = &er
 
+	cmd.Env = gitEnvironment(env)
+
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+
 	if cwd != "" {
 		cmd.Dir = cwd
 	}
 
-	cmd.Run()
+	err := cmd.Run()
+	if err != nil {
+		return result.String(), newGitError(command, err, result.String(), er.String())
+	}
+
+	return result.String(), nil
+}
+
+// The current environment with env on top. Errors are told apart by git's English messages, so
+// they must not be translated.
+func gitEnvironment(env []string) []string {
+	return append(append(os.Environ(), "LC_ALL=C"), env...)
+}
+
+func newGitError(command []string, err error, stdout string, stderr string) *GitError {
+	gitErr := &GitError{
+		Command:  command,
+		ExitCode: -1,
+		Stderr:   stderr,
+		Kind:     classifyError(stdout, stderr),
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		gitErr.ExitCode = exitErr.ExitCode()
+	} else if gitErr.Stderr == "" {
+		gitErr.Stderr = err.Error()
+	}
+
+	if gitErr.Stderr == "" && gitErr.Kind == nil {
+		gitErr.Stderr = stdout
+	}
 
-	return result.String()
+	return gitErr
 }
@@ -3,32 +3,255 @@ package git
 import (
 	"strconv"
 	"strings"
+	"time"
 )
 
+// Parses the output of `git status --porcelain=v2 -z`
+// https://git-scm.com/docs/git-status#_porcelain_format_version_2
 func ParseStatus(text string) (result []GitStatusEntry) {
 	if text == "" {
 		return
 	}
 
-	lines := strings.Split(text, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
+	records := strings.Split(text, "\x00")
+	for index := 0; index < len(records); index += 1 {
+		record := records[index]
+		if record == "" {
 			continue
 		}
 
-		entryType, entryName, _ := strings.Cut(trimmed, " ")
+		switch record[0] {
+		case '1':
+			fields := strings.SplitN(record, " ", 9)
+			if len(fields) < 9 {
+				continue
+			}
+
+			entry := newStatusEntry(fields[8], fields[1], fields[2])
+			entry.HeadMode, entry.IndexMode, entry.WorktreeMode = fields[3], fields[4], fields[5]
+
+			result = append(result, entry)
+		case '2':
+			fields := strings.SplitN(record, " ", 10)
+			if len(fields) < 10 {
+				continue
+			}
 
-		result = append(result, GitStatusEntry{
-			Filename: strings.TrimSpace(entryName),
-			Type:     stringToChangeType(entryType),
-			Selected: true,
-		})
+			entry := newStatusEntry(fields[9], fields[1], fields[2])
+			entry.HeadMode, entry.IndexMode, entry.WorktreeMode = fields[3], fields[4], fields[5]
+
+			// With -z the original path is the next record rather than being separated by a tab
+			if index+1 < len(records) {
+				index += 1
+				entry.OrigFilename = records[index]
+			}
+
+			result = append(result, entry)
+		case 'u':
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) < 11 {
+				continue
+			}
+
+			// The modes are of the common ancestor, our side, their side and the worktree
+			entry := newStatusEntry(fields[10], fields[1], fields[2])
+			entry.HeadMode, entry.IndexMode, entry.WorktreeMode = fields[4], fields[5], fields[6]
+			entry.Type = GIT_ENTRY_CONFLICTED
+
+			result = append(result, entry)
+		case '?':
+			result = append(result, GitStatusEntry{
+				Filename:      strings.TrimPrefix(record, "? "),
+				Type:          GIT_ENTRY_NEW_UNSTAGED,
+				IndexState:    GIT_STATE_UNTRACKED,
+				WorktreeState: GIT_STATE_UNTRACKED,
+			})
+		default:
+			// Headers (#) and ignored files (!) are not interesting to us
+		}
 	}
 
 	return
 }
 
+// Parses `git diff --name-status -z` output into entries that describe the worktree side of a change
+func ParseNameStatus(text string) (result []GitStatusEntry) {
+	tokens := strings.Split(text, "\x00")
+
+	for index := 0; index < len(tokens); index += 1 {
+		status := tokens[index]
+		if status == "" || index+1 >= len(tokens) {
+			continue
+		}
+
+		entry := GitStatusEntry{
+			IndexState:    GIT_STATE_UNMODIFIED,
+			WorktreeState: byteToFileState(status[0]),
+		}
+
+		// Renames and copies come with a similarity score and both paths, the old one first
+		if (status[0] == 'R' || status[0] == 'C') && index+2 < len(tokens) {
+			entry.OrigFilename = tokens[index+1]
+			entry.Filename = tokens[index+2]
+			index += 2
+		} else {
+			entry.Filename = tokens[index+1]
+			index += 1
+		}
+
+		entry.Type = fileStatesToChangeType(GIT_STATE_UNMODIFIED, entry.WorktreeState)
+		result = append(result, entry)
+	}
+
+	return
+}
+
+// Parses the output of `git log` with logFormat
+func ParseLog(text string) (result []GitCommit) {
+	fields := strings.Split(text, "\x00")
+
+	for index := 0; index+8 <= len(fields); index += 8 {
+		hash := strings.TrimSpace(fields[index])
+		if hash == "" {
+			break
+		}
+
+		commit := GitCommit{
+			Hash:        hash,
+			ShortHash:   fields[index+1],
+			AuthorName:  fields[index+3],
+			AuthorEmail: fields[index+4],
+			Subject:     fields[index+7],
+		}
+
+		if fields[index+2] != "" {
+			commit.Parents = strings.Split(fields[index+2], " ")
+		}
+
+		timestamp, err := strconv.ParseInt(fields[index+5], 10, 64)
+		if err == nil {
+			commit.AuthorDate = time.Unix(timestamp, 0)
+		}
+
+		if fields[index+6] != "" {
+			commit.Refs = strings.Split(fields[index+6], ", ")
+		}
+
+		result = append(result, commit)
+	}
+
+	return
+}
+
+// Parses the output of `git blame --porcelain`
+// https://git-scm.com/docs/git-blame#_the_porcelain_format
+func ParseBlame(text string) (result GitBlame) {
+	result.Commits = make(map[string]GitBlameCommit)
+
+	var current GitBlameLine
+	var commit GitBlameCommit
+	inHeader := false
+
+	// The filename is only printed once per commit unless it changes
+	filenames := make(map[string]string)
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			current.Text = strings.ReplaceAll(line[1:], "\t", "    ")
+
+			result.Commits[commit.Hash] = commit
+			result.Lines = append(result.Lines, current)
+
+			inHeader = false
+			continue
+		}
+
+		if !inHeader {
+			fields := strings.Split(line, " ")
+			if len(fields) < 3 {
+				continue
+			}
+
+			current = GitBlameLine{Hash: fields[0]}
+
+			origLine, _ := strconv.Atoi(fields[1])
+			finalLine, _ := strconv.Atoi(fields[2])
+			current.OrigLine = uint32(origLine)
+			current.FinalLine = uint32(finalLine)
+
+			if existing, ok := result.Commits[current.Hash]; ok {
+				commit = existing
+				current.Filename = filenames[current.Hash]
+			} else {
+				commit = GitBlameCommit{Hash: current.Hash}
+			}
+
+			inHeader = true
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			commit.AuthorName = value
+		case "author-mail":
+			commit.AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			timestamp, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				commit.AuthorDate = time.Unix(timestamp, 0)
+			}
+		case "summary":
+			commit.Summary = value
+		case "previous":
+			commit.PreviousHash, commit.PreviousFilename, _ = strings.Cut(value, " ")
+		case "boundary":
+			commit.Boundary = true
+		case "filename":
+			current.Filename = value
+			filenames[current.Hash] = value
+		}
+	}
+
+	return
+}
+
+// Parses a progress line that git prints to stderr with --progress, for example
+// "Receiving objects:  45% (9/20), 1.2 MiB | 2.0 MiB/s"
+func ParseProgress(line string) (result GitProgress, ok bool) {
+	line = strings.TrimPrefix(strings.TrimSpace(line), "remote: ")
+
+	phase, rest, found := strings.Cut(line, ":")
+	if !found {
+		return
+	}
+
+	rest = strings.TrimSpace(rest)
+	percentText, rest, found := strings.Cut(rest, "%")
+	if !found {
+		return
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSpace(percentText))
+	if err != nil {
+		return
+	}
+
+	result.Phase = phase
+	result.Percent = percent
+
+	// The counts are optional, e.g. "Resolving deltas: 100% (3/3)"
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "(") {
+		counts, _, _ := strings.Cut(rest[1:], ")")
+		current, total, _ := strings.Cut(counts, "/")
+		result.Current, _ = strconv.Atoi(current)
+		result.Total, _ = strconv.Atoi(total)
+	}
+
+	return result, true
+}
+
 func ParseBranches(text string) (result []string) {
 	if text == "" {
 		return
@@ -45,29 +268,223 @@ func ParseBranches(text string) (result []string) {
 	return
 }
 
+func ParseBranchDetails(text string) (result []GitBranch) {
+	fields := strings.Split(text, "\x00")
+
+	for index := 0; index+6 <= len(fields); index += 6 {
+		refName := strings.TrimSpace(fields[index])
+		if refName == "" {
+			break
+		}
+
+		// Every remote has a HEAD that only points to one of its other branches
+		if strings.HasPrefix(refName, "refs/remotes/") && strings.HasSuffix(refName, "/HEAD") {
+			continue
+		}
+
+		branch := GitBranch{
+			Name:     fields[index+1],
+			Remote:   strings.HasPrefix(refName, "refs/remotes/"),
+			Current:  fields[index+2] == "*",
+			Upstream: fields[index+3],
+		}
+
+		// e.g. "ahead 1, behind 2" or "gone"
+		for _, part := range strings.Split(fields[index+4], ", ") {
+			if part == "gone" {
+				branch.UpstreamGone = true
+			} else if strings.HasPrefix(part, "ahead ") {
+				branch.Ahead, _ = strconv.Atoi(strings.TrimPrefix(part, "ahead "))
+			} else if strings.HasPrefix(part, "behind ") {
+				branch.Behind, _ = strconv.Atoi(strings.TrimPrefix(part, "behind "))
+			}
+		}
+
+		branch.LastCommitDate, _ = strconv.ParseInt(fields[index+5], 10, 64)
+
+		result = append(result, branch)
+	}
+
+	return
+}
+
+func ParseTags(text string) (result []GitTag) {
+	fields := strings.Split(text, "\x00")
+
+	for index := 0; index+6 <= len(fields); index += 6 {
+		name := strings.TrimSpace(fields[index])
+		if name == "" {
+			break
+		}
+
+		tag := GitTag{
+			Name:      name,
+			Annotated: fields[index+1] == "tag",
+			Target:    fields[index+2],
+			Message:   fields[index+4],
+		}
+
+		if tag.Annotated && fields[index+3] != "" {
+			tag.Target = fields[index+3]
+		}
+
+		timestamp, err := strconv.ParseInt(fields[index+5], 10, 64)
+		if err == nil {
+			tag.Date = time.Unix(timestamp, 0)
+		}
+
+		result = append(result, tag)
+	}
+
+	return
+}
+
+// Expects the output of `git stash list --format=%gd%x00%H%x00%ct%x00%gs%x00`
 func ParseStashList(text string) (result []GitStashEntry) {
+	fields := strings.Split(text, "\x00")
+
+	for index := 0; index+4 <= len(fields); index += 4 {
+		stashIndex := strings.TrimSpace(fields[index])
+		if stashIndex == "" {
+			break
+		}
+
+		entry := GitStashEntry{
+			Index: stashIndex,
+			Hash:  fields[index+1],
+		}
+
+		timestamp, err := strconv.ParseInt(fields[index+2], 10, 64)
+		if err == nil {
+			entry.Date = time.Unix(timestamp, 0)
+		}
+
+		// The subject is either "WIP on <branch>: <head commit>" or "On <branch>: <message>", where
+		// the branch is "(no branch)" on a detached HEAD. Branch names can't contain a colon, but the
+		// message after it can. `git stash store -m` keeps any message as it is.
+		subject := fields[index+3]
+		entry.Message = subject
+
+		for _, prefix := range []string{"WIP on ", "On "} {
+			if !strings.HasPrefix(subject, prefix) {
+				continue
+			}
+
+			if branch, message, found := strings.Cut(strings.TrimPrefix(subject, prefix), ": "); found {
+				entry.BranchName = branch
+				entry.Message = message
+			}
+
+			break
+		}
+
+		result = append(result, entry)
+	}
+
+	return
+}
+
+// Splits a file with conflict markers into sections. A conflict that is never closed is kept
+// as ordinary text.
+func ParseConflicts(text string) (result []GitConflictSection) {
 	if text == "" {
 		return
 	}
 
-	lines := strings.Split(text, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var common []string
+	for index := 0; index < len(lines); {
+		section, next, ok := parseConflictSection(lines, index)
+		if !ok {
+			common = append(common, lines[index])
+			index += 1
 			continue
 		}
 
-		split := strings.Split(trimmed, ":")
+		if len(common) > 0 {
+			result = append(result, GitConflictSection{Common: common})
+			common = nil
+		}
+
+		result = append(result, section)
+		index = next
+	}
 
-		result = append(result, GitStashEntry{
-			Index:      split[0],
-			BranchName: strings.Replace(strings.TrimSpace(split[1]), "WIP on ", "", 1),
-		})
+	if len(common) > 0 {
+		result = append(result, GitConflictSection{Common: common})
 	}
 
 	return
 }
 
+func parseConflictSection(lines []string, start int) (result GitConflictSection, next int, ok bool) {
+	label, isMarker := parseConflictMarker(lines[start], '<')
+	if !isMarker {
+		return
+	}
+
+	result.IsConflict = true
+	result.OursLabel = label
+
+	// 0 - ours, 1 - base, 2 - theirs
+	part := 0
+	for index := start + 1; index < len(lines); index += 1 {
+		line := lines[index]
+
+		if part == 0 {
+			if _, isBase := parseConflictMarker(line, '|'); isBase {
+				part = 1
+				continue
+			}
+		}
+		if part < 2 {
+			if _, isSeparator := parseConflictMarker(line, '='); isSeparator {
+				part = 2
+				continue
+			}
+		}
+		if part == 2 {
+			if label, isEnd := parseConflictMarker(line, '>'); isEnd {
+				result.TheirsLabel = label
+				result.Raw = lines[start : index+1]
+
+				return result, index + 1, true
+			}
+		}
+
+		if part == 0 {
+			result.Ours = append(result.Ours, line)
+		} else if part == 1 {
+			result.Base = append(result.Base, line)
+		} else {
+			result.Theirs = append(result.Theirs, line)
+		}
+	}
+
+	return GitConflictSection{}, start, false
+}
+
+// Checks whether the line is a conflict marker made of seven of the given character and returns the label after it
+func parseConflictMarker(line string, marker byte) (string, bool) {
+	trimmed := strings.TrimRight(line, "\r\n")
+	prefix := strings.Repeat(string(marker), 7)
+
+	if !strings.HasPrefix(trimmed, prefix) {
+		return "", false
+	}
+
+	rest := trimmed[len(prefix):]
+	if rest != "" && rest[0] != ' ' {
+		return "", false
+	}
+
+	return strings.TrimSpace(rest), true
+}
+
 // How to read diff output
 // https://stackoverflow.com/questions/27508982/interpreting-git-diff-output
 func ParseDiff(text string) (result GitDiff) {
@@ -102,10 +519,13 @@ func ParseDiff(text string) (result GitDiff) {
 		return
 	}
 
+	result.Header = lines[:chunkStart]
 	lines = lines[chunkStart:]
 
 	tempChunksOld := make([][]string, 0)
 	tempChunksNew := make([][]string, 0)
+	tempRawOld := make([][]int, 0)
+	tempRawNew := make([][]int, 0)
 
 	// First parse the chunks into their respective sides without turning them into GitDiffLines
 	for _, line := range lines {
@@ -114,6 +534,15 @@ func ParseDiff(text string) (result GitDiff) {
 			continue
 		}
 
+		if strings.HasPrefix(trimmed, "@@") {
+			result.RawChunks = append(result.RawChunks, []string{line})
+		} else {
+			lastChunk := len(result.RawChunks) - 1
+			result.RawChunks[lastChunk] = append(result.RawChunks[lastChunk], line)
+		}
+
+		rawIndex := len(result.RawChunks[len(result.RawChunks)-1]) - 1
+
 		if strings.HasPrefix(trimmed, "@@") {
 			nextOldChunk := GitDiffFile{}
 			nextNewChunk := GitDiffFile{}
@@ -125,18 +554,24 @@ func ParseDiff(text string) (result GitDiff) {
 
 			tempChunksOld = append(tempChunksOld, []string{})
 			tempChunksNew = append(tempChunksNew, []string{})
+			tempRawOld = append(tempRawOld, []int{})
+			tempRawNew = append(tempRawNew, []int{})
 		} else if strings.HasPrefix(trimmed, "+") {
 			lastChunk := len(tempChunksNew) - 1
 			tempChunksNew[lastChunk] = append(tempChunksNew[lastChunk], trimmed)
+			tempRawNew[lastChunk] = append(tempRawNew[lastChunk], rawIndex)
 		} else if strings.HasPrefix(trimmed, "-") {
 			lastChunk := len(tempChunksOld) - 1
 			tempChunksOld[lastChunk] = append(tempChunksOld[lastChunk], trimmed)
+			tempRawOld[lastChunk] = append(tempRawOld[lastChunk], rawIndex)
 		} else if strings.HasPrefix(trimmed, "\\") {
 			// Ignore these kinds of lines
 		} else {
 			lastChunk := len(tempChunksNew) - 1
 			tempChunksNew[lastChunk] = append(tempChunksNew[lastChunk], trimmed)
 			tempChunksOld[lastChunk] = append(tempChunksOld[lastChunk], trimmed)
+			tempRawNew[lastChunk] = append(tempRawNew[lastChunk], rawIndex)
+			tempRawOld[lastChunk] = append(tempRawOld[lastChunk], rawIndex)
 		}
 	}
 
@@ -145,56 +580,63 @@ func ParseDiff(text string) (result GitDiff) {
 		oldIndex := 0
 		newIndex := 0
 
-		for true {
+		for oldIndex < len(tempChunksOld[chIndex]) || newIndex < len(tempChunksNew[chIndex]) {
 			if oldIndex < len(tempChunksOld[chIndex]) && newIndex < len(tempChunksNew[chIndex]) && !strings.HasPrefix(tempChunksOld[chIndex][oldIndex], "-") && !strings.HasPrefix(tempChunksNew[chIndex][newIndex], "+") {
 				result.NewChunks[chIndex].Lines = append(result.NewChunks[chIndex].Lines, GitDiffLine{
-					Text: tempChunksNew[chIndex][newIndex],
-					Type: GIT_LINE_UNMODIFIED,
+					Text:     tempChunksNew[chIndex][newIndex],
+					Type:     GIT_LINE_UNMODIFIED,
+					RawIndex: tempRawNew[chIndex][newIndex],
 				})
 				result.OldChunks[chIndex].Lines = append(result.OldChunks[chIndex].Lines, GitDiffLine{
-					Text: tempChunksOld[chIndex][oldIndex],
-					Type: GIT_LINE_UNMODIFIED,
+					Text:     tempChunksOld[chIndex][oldIndex],
+					Type:     GIT_LINE_UNMODIFIED,
+					RawIndex: tempRawOld[chIndex][oldIndex],
 				})
 
 				oldIndex += 1
 				newIndex += 1
 			} else if oldIndex < len(tempChunksOld[chIndex]) && newIndex < len(tempChunksNew[chIndex]) && strings.HasPrefix(tempChunksOld[chIndex][oldIndex], "-") && strings.HasPrefix(tempChunksNew[chIndex][newIndex], "+") {
 				result.NewChunks[chIndex].Lines = append(result.NewChunks[chIndex].Lines, GitDiffLine{
-					Text: tempChunksNew[chIndex][newIndex][1:],
-					Type: GIT_LINE_NEW,
+					Text:     tempChunksNew[chIndex][newIndex][1:],
+					Type:     GIT_LINE_NEW,
+					RawIndex: tempRawNew[chIndex][newIndex],
 				})
 				result.OldChunks[chIndex].Lines = append(result.OldChunks[chIndex].Lines, GitDiffLine{
-					Text: tempChunksOld[chIndex][oldIndex][1:],
-					Type: GIT_LINE_REMOVED,
+					Text:     tempChunksOld[chIndex][oldIndex][1:],
+					Type:     GIT_LINE_REMOVED,
+					RawIndex: tempRawOld[chIndex][oldIndex],
 				})
 
 				oldIndex += 1
 				newIndex += 1
 			} else if newIndex < len(tempChunksNew[chIndex]) && (oldIndex >= len(tempChunksOld[chIndex]) || !strings.HasPrefix(tempChunksOld[chIndex][oldIndex], "-")) && strings.HasPrefix(tempChunksNew[chIndex][newIndex], "+") {
 				result.NewChunks[chIndex].Lines = append(result.NewChunks[chIndex].Lines, GitDiffLine{
-					Text: tempChunksNew[chIndex][newIndex][1:],
-					Type: GIT_LINE_NEW,
+					Text:     tempChunksNew[chIndex][newIndex][1:],
+					Type:     GIT_LINE_NEW,
+					RawIndex: tempRawNew[chIndex][newIndex],
 				})
 				result.OldChunks[chIndex].Lines = append(result.OldChunks[chIndex].Lines, GitDiffLine{
-					Text: "",
-					Type: GIT_LINE_EMPTY,
+					Text:     "",
+					Type:     GIT_LINE_EMPTY,
+					RawIndex: -1,
 				})
 
 				newIndex += 1
 			} else if oldIndex < len(tempChunksOld[chIndex]) && strings.HasPrefix(tempChunksOld[chIndex][oldIndex], "-") && (newIndex >= len(tempChunksNew[chIndex]) || !strings.HasPrefix(tempChunksNew[chIndex][newIndex], "+")) {
 				result.OldChunks[chIndex].Lines = append(result.OldChunks[chIndex].Lines, GitDiffLine{
-					Text: tempChunksOld[chIndex][oldIndex][1:],
-					Type: GIT_LINE_REMOVED,
+					Text:     tempChunksOld[chIndex][oldIndex][1:],
+					Type:     GIT_LINE_REMOVED,
+					RawIndex: tempRawOld[chIndex][oldIndex],
 				})
 				result.NewChunks[chIndex].Lines = append(result.NewChunks[chIndex].Lines, GitDiffLine{
-					Text: "",
-					Type: GIT_LINE_EMPTY,
+					Text:     "",
+					Type:     GIT_LINE_EMPTY,
+					RawIndex: -1,
 				})
 
 				oldIndex += 1
-			}
-
-			if oldIndex >= len(tempChunksOld[chIndex]) && newIndex >= len(tempChunksNew[chIndex]) {
+			} else {
+				// Context lines only ever come in pairs, so this can only be reached with malformed input
 				break
 			}
 		}
@@ -242,17 +684,65 @@ func parseChunkRange(line string) (uint32, uint32, uint32, uint32) {
 	return uint32(oldStart), uint32(oldEnd), uint32(newStart), uint32(newEnd)
 }
 
-func stringToChangeType(str string) GitStatusEntryType {
-	switch str {
-	case "M":
-		return GIT_ENTRY_MODIFIED
-	case "??":
-		return GIT_ENTRY_NEW_UNSTAGED
-	case "A":
-		return GIT_ENTRY_NEW
-	case "D":
+func newStatusEntry(filename string, xy string, submodule string) (result GitStatusEntry) {
+	result.Filename = filename
+
+	if len(xy) == 2 {
+		result.IndexState = byteToFileState(xy[0])
+		result.WorktreeState = byteToFileState(xy[1])
+	}
+
+	if len(submodule) == 4 && submodule[0] == 'S' {
+		result.Submodule = GitSubmoduleState{
+			IsSubmodule:       true,
+			CommitChanged:     submodule[1] == 'C',
+			HasTrackedChanges: submodule[2] == 'M',
+			HasUntrackedFiles: submodule[3] == 'U',
+		}
+	}
+
+	result.Type = fileStatesToChangeType(result.IndexState, result.WorktreeState)
+
+	return
+}
+
+func fileStatesToChangeType(index GitFileState, worktree GitFileState) GitStatusEntryType {
+	if index == GIT_STATE_UPDATED_UNMERGED || worktree == GIT_STATE_UPDATED_UNMERGED {
+		return GIT_ENTRY_CONFLICTED
+	} else if worktree == GIT_STATE_DELETED || index == GIT_STATE_DELETED {
 		return GIT_ENTRY_DELETED
+	} else if index == GIT_STATE_RENAMED || worktree == GIT_STATE_RENAMED {
+		return GIT_ENTRY_RENAMED
+	} else if index == GIT_STATE_COPIED || worktree == GIT_STATE_COPIED {
+		return GIT_ENTRY_COPIED
+	} else if index == GIT_STATE_ADDED || worktree == GIT_STATE_ADDED {
+		return GIT_ENTRY_NEW
+	} else if index == GIT_STATE_TYPE_CHANGED || worktree == GIT_STATE_TYPE_CHANGED {
+		return GIT_ENTRY_TYPE_CHANGED
+	}
+
+	return GIT_ENTRY_MODIFIED
+}
+
+func byteToFileState(b byte) GitFileState {
+	switch b {
+	case 'M':
+		return GIT_STATE_MODIFIED
+	case 'T':
+		return GIT_STATE_TYPE_CHANGED
+	case 'A':
+		return GIT_STATE_ADDED
+	case 'D':
+		return GIT_STATE_DELETED
+	case 'R':
+		return GIT_STATE_RENAMED
+	case 'C':
+		return GIT_STATE_COPIED
+	case 'U':
+		return GIT_STATE_UPDATED_UNMERGED
+	case '?':
+		return GIT_STATE_UNTRACKED
 	default:
-		panic("Unreachable")
+		return GIT_STATE_UNMODIFIED
 	}
 }
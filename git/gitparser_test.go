@@ -0,0 +1,208 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []GitStatusEntry
+	}{
+		{
+			"headers and ignored files are skipped",
+			"# branch.oid abc\x00# branch.head main\x00! build/out.o\x00",
+			nil,
+		},
+		{
+			"modified path with spaces",
+			"1 .M N... 100644 100644 100644 h1 h2 dir/my file.txt\x00",
+			[]GitStatusEntry{{
+				Filename: "dir/my file.txt", Type: GIT_ENTRY_MODIFIED,
+				IndexState: GIT_STATE_UNMODIFIED, WorktreeState: GIT_STATE_MODIFIED,
+				HeadMode: "100644", IndexMode: "100644", WorktreeMode: "100644",
+			}},
+		},
+		{
+			"paths with quotes and newlines are not quoted with -z",
+			"1 A. N... 000000 100644 100644 h1 h2 say \"hi\".txt\x00? new\nline.txt\x00",
+			[]GitStatusEntry{{
+				Filename: "say \"hi\".txt", Type: GIT_ENTRY_NEW,
+				IndexState: GIT_STATE_ADDED, WorktreeState: GIT_STATE_UNMODIFIED,
+				HeadMode: "000000", IndexMode: "100644", WorktreeMode: "100644",
+			}, {
+				Filename: "new\nline.txt", Type: GIT_ENTRY_NEW_UNSTAGED,
+				IndexState: GIT_STATE_UNTRACKED, WorktreeState: GIT_STATE_UNTRACKED,
+			}},
+		},
+		{
+			"rename with the original path in the next record",
+			"2 R. N... 100644 100644 100644 h1 h2 R100 new name.txt\x00old name.txt\x00",
+			[]GitStatusEntry{{
+				Filename: "new name.txt", OrigFilename: "old name.txt", Type: GIT_ENTRY_RENAMED,
+				IndexState: GIT_STATE_RENAMED, WorktreeState: GIT_STATE_UNMODIFIED,
+				HeadMode: "100644", IndexMode: "100644", WorktreeMode: "100644",
+			}},
+		},
+		{
+			"copy followed by another entry",
+			"2 C. N... 100755 100755 100755 h1 h2 C75 copy.sh\x00script.sh\x00? other\x00",
+			[]GitStatusEntry{{
+				Filename: "copy.sh", OrigFilename: "script.sh", Type: GIT_ENTRY_COPIED,
+				IndexState: GIT_STATE_COPIED, WorktreeState: GIT_STATE_UNMODIFIED,
+				HeadMode: "100755", IndexMode: "100755", WorktreeMode: "100755",
+			}, {
+				Filename: "other", Type: GIT_ENTRY_NEW_UNSTAGED,
+				IndexState: GIT_STATE_UNTRACKED, WorktreeState: GIT_STATE_UNTRACKED,
+			}},
+		},
+		{
+			"both modified",
+			"u UU N... 100644 100644 100755 100644 h1 h2 h3 both.txt\x00",
+			[]GitStatusEntry{{
+				Filename: "both.txt", Type: GIT_ENTRY_CONFLICTED,
+				IndexState: GIT_STATE_UPDATED_UNMERGED, WorktreeState: GIT_STATE_UPDATED_UNMERGED,
+				HeadMode: "100644", IndexMode: "100755", WorktreeMode: "100644",
+			}},
+		},
+		{
+			"both added has no common ancestor",
+			"u AA N... 000000 100644 100644 100644 h1 h2 h3 added.txt\x00",
+			[]GitStatusEntry{{
+				Filename: "added.txt", Type: GIT_ENTRY_CONFLICTED,
+				IndexState: GIT_STATE_ADDED, WorktreeState: GIT_STATE_ADDED,
+				HeadMode: "100644", IndexMode: "100644", WorktreeMode: "100644",
+			}},
+		},
+		{
+			"deleted by us",
+			"u DU N... 100644 000000 100644 100644 h1 h2 h3 deleted.txt\x00",
+			[]GitStatusEntry{{
+				Filename: "deleted.txt", Type: GIT_ENTRY_CONFLICTED,
+				IndexState: GIT_STATE_DELETED, WorktreeState: GIT_STATE_UPDATED_UNMERGED,
+				HeadMode: "000000", IndexMode: "100644", WorktreeMode: "100644",
+			}},
+		},
+		{
+			"submodule with a new commit and untracked files",
+			"1 .M SC.U 160000 160000 160000 h1 h2 vendor/lib\x00",
+			[]GitStatusEntry{{
+				Filename: "vendor/lib", Type: GIT_ENTRY_MODIFIED,
+				IndexState: GIT_STATE_UNMODIFIED, WorktreeState: GIT_STATE_MODIFIED,
+				HeadMode: "160000", IndexMode: "160000", WorktreeMode: "160000",
+				Submodule: GitSubmoduleState{IsSubmodule: true, CommitChanged: true, HasUntrackedFiles: true},
+			}},
+		},
+		{
+			"truncated records are skipped",
+			"1 .M N... 100644\x00u UU N...\x002 R. N... 100644 100644 100644 h1 h2 R100 renamed.txt",
+			[]GitStatusEntry{{
+				Filename: "renamed.txt", Type: GIT_ENTRY_RENAMED,
+				IndexState: GIT_STATE_RENAMED, WorktreeState: GIT_STATE_UNMODIFIED,
+				HeadMode: "100644", IndexMode: "100644", WorktreeMode: "100644",
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		if got := ParseStatus(test.output); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStatusWithUnusualPaths(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "renamed from.txt", "1\n2\n3\n", "Add file")
+
+	// In the order git lists them, which is by bytes
+	names := []string{"with\nnewline.txt", "with \"quotes\".txt", "with space.txt", "ümlaut.txt"}
+	for _, name := range names {
+		writeTestFile(t, dir, name, name+"\n")
+	}
+	runTestGit(t, dir, "mv", "renamed from.txt", "renamed to.txt")
+
+	entries, err := Status(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.OrigFilename+" -> "+entry.Filename)
+	}
+
+	want := []string{"renamed from.txt -> renamed to.txt"}
+	for _, name := range names {
+		want = append(want, " -> "+name)
+	}
+
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseStashList(t *testing.T) {
+	output := "stash@{0}\x00h0\x001700000000\x00On main: fix: handle a: b\x00" +
+		"stash@{1}\x00h1\x001700000000\x00WIP on feature/x: abc1234 Subject: with colon\x00" +
+		"stash@{2}\x00h2\x00bad\x00On (no branch): detached work\x00" +
+		"stash@{3}\x00h3\x001700000000\x00custom: stored message\x00" +
+		"stash@{4}\x00h4\x001700000000\x00no colon at all\x00\n"
+
+	want := []struct {
+		index   string
+		branch  string
+		message string
+	}{
+		{"stash@{0}", "main", "fix: handle a: b"},
+		{"stash@{1}", "feature/x", "abc1234 Subject: with colon"},
+		{"stash@{2}", "(no branch)", "detached work"},
+		{"stash@{3}", "", "custom: stored message"},
+		{"stash@{4}", "", "no colon at all"},
+	}
+
+	entries := ParseStashList(output)
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+
+	for index, entry := range entries {
+		if entry.Index != want[index].index || entry.BranchName != want[index].branch || entry.Message != want[index].message {
+			t.Errorf("entry %d: got %q on %q as %s, want %q on %q", index, entry.Message, entry.BranchName, entry.Index, want[index].message, want[index].branch)
+		}
+	}
+
+	if entries[0].Date.Unix() != 1700000000 || !entries[2].Date.IsZero() {
+		t.Errorf("got dates %v and %v", entries[0].Date, entries[2].Date)
+	}
+}
+
+func TestListStashOnDetachedHead(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "one\n", "First")
+
+	writeTestFile(t, dir, "file.txt", "two\n")
+	runTestGit(t, dir, "stash", "push", "-q", "-m", "fix: keep: colons")
+
+	runTestGit(t, dir, "checkout", "-q", "--detach")
+	writeTestFile(t, dir, "file.txt", "three\n")
+	runTestGit(t, dir, "stash", "push", "-q", "-m", "detached")
+
+	entries, err := ListStash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+
+	if entries[0].BranchName != "(no branch)" || entries[0].Message != "detached" {
+		t.Errorf("detached stash is %q on %q", entries[0].Message, entries[0].BranchName)
+	}
+	if entries[1].BranchName != "main" || entries[1].Message != "fix: keep: colons" {
+		t.Errorf("stash with colons is %q on %q", entries[1].Message, entries[1].BranchName)
+	}
+}
@@ -0,0 +1,136 @@
+package git
+
+// One row of the commit graph. Lines in Top go from the top edge of the row to its middle,
+// lines in Bottom go from the middle to the bottom edge. The commit itself sits in the middle of Column.
+type GitGraphRow struct {
+	Column int
+	Color  int
+	Top    []GitGraphLine
+	Bottom []GitGraphLine
+}
+
+type GitGraphLine struct {
+	From  int
+	To    int
+	Color int
+}
+
+// Assigns commits to lanes the way `git log --graph` does. The state is kept between calls to
+// AddCommits so that only newly loaded commits need to be laid out.
+type GitGraph struct {
+	Rows []GitGraphRow
+
+	// Hash of the commit each lane is waiting for, empty for free lanes
+	lanes      []string
+	laneColors []int
+	nextColor  int
+}
+
+func (graph *GitGraph) Clear() {
+	graph.Rows = nil
+	graph.lanes = nil
+	graph.laneColors = nil
+	graph.nextColor = 0
+}
+
+// Commits have to be in the order `git log` printed them, following the commits added before
+func (graph *GitGraph) AddCommits(commits []GitCommit) {
+	for _, commit := range commits {
+		graph.Rows = append(graph.Rows, graph.addCommit(commit))
+	}
+}
+
+func (graph *GitGraph) addCommit(commit GitCommit) (result GitGraphRow) {
+	before := append([]string{}, graph.lanes...)
+
+	column := -1
+	for index, hash := range graph.lanes {
+		if hash == commit.Hash {
+			if column == -1 {
+				column = index
+			} else {
+				// Another branch ends up in this commit as well, its lane is free from now on
+				graph.lanes[index] = ""
+			}
+		}
+	}
+
+	if column == -1 {
+		column = graph.allocateLane(commit.Hash)
+	}
+
+	result.Column = column
+	result.Color = graph.laneColors[column]
+
+	for index, hash := range before {
+		if hash == "" {
+			continue
+		}
+
+		if hash == commit.Hash {
+			result.Top = append(result.Top, GitGraphLine{From: index, To: column, Color: graph.laneColors[index]})
+		} else {
+			result.Top = append(result.Top, GitGraphLine{From: index, To: index, Color: graph.laneColors[index]})
+		}
+	}
+
+	graph.lanes[column] = ""
+	parentColumns := make(map[int]bool)
+
+	for parentIndex, parent := range commit.Parents {
+		parentColumn := -1
+		for index, hash := range graph.lanes {
+			if hash == parent {
+				parentColumn = index
+				break
+			}
+		}
+
+		if parentColumn == -1 {
+			if parentIndex == 0 {
+				// The first parent continues the commit's own lane
+				parentColumn = column
+				graph.lanes[column] = parent
+			} else {
+				parentColumn = graph.allocateLane(parent)
+			}
+		}
+
+		parentColumns[parentColumn] = true
+		result.Bottom = append(result.Bottom, GitGraphLine{From: column, To: parentColumn, Color: graph.laneColors[parentColumn]})
+	}
+
+	for index, hash := range graph.lanes {
+		if hash != "" && !parentColumns[index] && index != column {
+			result.Bottom = append(result.Bottom, GitGraphLine{From: index, To: index, Color: graph.laneColors[index]})
+		}
+	}
+
+	graph.trimLanes()
+
+	return
+}
+
+func (graph *GitGraph) allocateLane(hash string) int {
+	graph.nextColor += 1
+
+	for index, lane := range graph.lanes {
+		if lane == "" {
+			graph.lanes[index] = hash
+			graph.laneColors[index] = graph.nextColor
+			return index
+		}
+	}
+
+	graph.lanes = append(graph.lanes, hash)
+	graph.laneColors = append(graph.laneColors, graph.nextColor)
+
+	return len(graph.lanes) - 1
+}
+
+func (graph *GitGraph) trimLanes() {
+	for len(graph.lanes) > 0 && graph.lanes[len(graph.lanes)-1] == "" {
+		graph.lanes = graph.lanes[:len(graph.lanes)-1]
+		graph.laneColors = graph.laneColors[:len(graph.laneColors)-1]
+	}
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Creates an empty repository on main that doesn't see the user's or the system's git config
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	runTestGit(t, dir, "init", "-q", "-b", "main")
+	runTestGit(t, dir, "config", "user.name", "Test")
+	runTestGit(t, dir, "config", "user.email", "test@example.com")
+	runTestGit(t, dir, "config", "commit.gpgsign", "false")
+
+	return dir
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	output, err := executeGit(args, dir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return output
+}
+
+func writeTestFile(t *testing.T, dir string, name string, contents string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func removeTestFile(t *testing.T, dir string, name string) {
+	t.Helper()
+
+	if err := os.Remove(filepath.Join(dir, name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, dir string, name string) string {
+	t.Helper()
+
+	contents, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(contents)
+}
+
+func commitTestFile(t *testing.T, dir string, name string, contents string, message string) {
+	t.Helper()
+
+	writeTestFile(t, dir, name, contents)
+	runTestGit(t, dir, "add", "--", name)
+	runTestGit(t, dir, "commit", "-q", "-m", message)
+}
+
+// The entry of the file on the index side if staged is set, on the worktree side otherwise
+func findTestEntry(t *testing.T, dir string, filename string, staged bool) GitStatusEntry {
+	t.Helper()
+
+	entries, err := Status(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stagedEntries, unstagedEntries := SplitStagedEntries(entries)
+	candidates := unstagedEntries
+	if staged {
+		candidates = stagedEntries
+	}
+
+	for _, entry := range candidates {
+		if entry.Filename == filename {
+			return entry
+		}
+	}
+
+	t.Fatalf("no entry for %s (staged: %v) in %+v", filename, staged, entries)
+	return GitStatusEntry{}
+}
+
+// Indices into the first hunk of the diff of the given patch lines, e.g. "+added"
+func rawTestIndices(t *testing.T, diff GitDiff, lines ...string) (result []int) {
+	t.Helper()
+
+	if len(diff.RawChunks) == 0 {
+		t.Fatal("diff has no hunks")
+	}
+
+	for _, line := range lines {
+		found := false
+		for index, raw := range diff.RawChunks[0] {
+			if index > 0 && raw == line {
+				result = append(result, index)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Fatalf("%q is not in the hunk %q", line, diff.RawChunks[0])
+		}
+	}
+
+	return
+}
+
+// The added and removed lines of `git diff` output, without the file headers
+func changedTestLines(output string) (result []string) {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "--- ") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			result = append(result, line)
+		}
+	}
+
+	return
+}
+
+func expectTestLines(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s: got %q, want %q", what, got, want)
+	}
+}
+
+// Checks the changed lines of `git diff --cached` and of `git diff`
+func expectTestDiffs(t *testing.T, dir string, staged []string, unstaged []string) {
+	t.Helper()
+
+	expectTestLines(t, "git diff --cached", changedTestLines(runTestGit(t, dir, "diff", "--cached")), staged...)
+	expectTestLines(t, "git diff", changedTestLines(runTestGit(t, dir, "diff")), unstaged...)
+}
@@ -0,0 +1,177 @@
+package git
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+type GitDiffGranularity uint8
+
+const (
+	GIT_DIFF_WORDS GitDiffGranularity = iota
+	GIT_DIFF_CHARACTERS
+)
+
+// Lines whose changed middle part has more tokens than this on both sides multiplied are not
+// compared token by token, the whole middle part is marked as changed instead
+const maxLineDiffCells = 250000
+
+// Byte offsets [Start, End) into the text of a line
+type GitTextRange struct {
+	Start int
+	End   int
+}
+
+// Fills in GitDiffLine.Changes for every removed line that sits on the same row as an added one
+func (diff *GitDiff) MarkChangedRanges(granularity GitDiffGranularity) {
+	for chIndex := 0; chIndex < len(diff.OldChunks) && chIndex < len(diff.NewChunks); chIndex += 1 {
+		oldLines := diff.OldChunks[chIndex].Lines
+		newLines := diff.NewChunks[chIndex].Lines
+
+		for row := 0; row < len(oldLines) && row < len(newLines); row += 1 {
+			oldLines[row].Changes = nil
+			newLines[row].Changes = nil
+
+			if oldLines[row].Type == GIT_LINE_REMOVED && newLines[row].Type == GIT_LINE_NEW {
+				oldLines[row].Changes, newLines[row].Changes = DiffLineRanges(oldLines[row].Text, newLines[row].Text, granularity)
+			}
+		}
+	}
+}
+
+// Returns the parts of both lines that are not part of their longest common subsequence of tokens.
+// Nothing is returned when the lines have nothing in common, highlighting everything adds nothing.
+func DiffLineRanges(oldText string, newText string, granularity GitDiffGranularity) (oldRanges []GitTextRange, newRanges []GitTextRange) {
+	oldTokens := tokenizeLine(oldText, granularity)
+	newTokens := tokenizeLine(newText, granularity)
+
+	prefix := 0
+	for prefix < len(oldTokens) && prefix < len(newTokens) && oldTokens[prefix].text == newTokens[prefix].text {
+		prefix += 1
+	}
+
+	suffix := 0
+	for suffix < len(oldTokens)-prefix && suffix < len(newTokens)-prefix && oldTokens[len(oldTokens)-1-suffix].text == newTokens[len(newTokens)-1-suffix].text {
+		suffix += 1
+	}
+
+	oldMiddle := oldTokens[prefix : len(oldTokens)-suffix]
+	newMiddle := newTokens[prefix : len(newTokens)-suffix]
+
+	oldChanged := make([]bool, len(oldMiddle))
+	newChanged := make([]bool, len(newMiddle))
+	for index := range oldChanged {
+		oldChanged[index] = true
+	}
+	for index := range newChanged {
+		newChanged[index] = true
+	}
+
+	common := prefix + suffix
+	if len(oldMiddle)*len(newMiddle) <= maxLineDiffCells {
+		common += markCommonTokens(oldMiddle, newMiddle, oldChanged, newChanged)
+	}
+
+	if common == 0 {
+		return
+	}
+
+	return tokenRanges(oldMiddle, oldChanged), tokenRanges(newMiddle, newChanged)
+}
+
+type lineToken struct {
+	text  string
+	start int
+}
+
+// Words are runs of letters, digits and underscores, runs of whitespace are a single token and
+// everything else is a token of its own
+func tokenizeLine(text string, granularity GitDiffGranularity) (result []lineToken) {
+	start := 0
+	for start < len(text) {
+		r, size := utf8.DecodeRuneInString(text[start:])
+
+		end := start + size
+		if granularity == GIT_DIFF_WORDS {
+			class := runeClass(r)
+			for class != 0 && end < len(text) {
+				next, nextSize := utf8.DecodeRuneInString(text[end:])
+				if runeClass(next) != class {
+					break
+				}
+
+				end += nextSize
+			}
+		}
+
+		result = append(result, lineToken{text: text[start:end], start: start})
+		start = end
+	}
+
+	return
+}
+
+// 1 for word characters, 2 for whitespace, 0 for characters that are always tokens of their own
+func runeClass(r rune) int {
+	if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return 1
+	} else if unicode.IsSpace(r) {
+		return 2
+	}
+
+	return 0
+}
+
+// Finds the longest common subsequence of both token lists, clears the changed flag of the tokens
+// that are part of it and returns its length
+func markCommonTokens(oldTokens []lineToken, newTokens []lineToken, oldChanged []bool, newChanged []bool) int {
+	width := len(newTokens) + 1
+
+	// lengths[i*width+j] is the length of the LCS of oldTokens[i:] and newTokens[j:]
+	lengths := make([]int32, (len(oldTokens)+1)*width)
+	for i := len(oldTokens) - 1; i >= 0; i -= 1 {
+		for j := len(newTokens) - 1; j >= 0; j -= 1 {
+			if oldTokens[i].text == newTokens[j].text {
+				lengths[i*width+j] = lengths[(i+1)*width+j+1] + 1
+			} else if lengths[(i+1)*width+j] >= lengths[i*width+j+1] {
+				lengths[i*width+j] = lengths[(i+1)*width+j]
+			} else {
+				lengths[i*width+j] = lengths[i*width+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldTokens) && j < len(newTokens) {
+		if oldTokens[i].text == newTokens[j].text {
+			oldChanged[i] = false
+			newChanged[j] = false
+			i += 1
+			j += 1
+		} else if lengths[(i+1)*width+j] >= lengths[i*width+j+1] {
+			i += 1
+		} else {
+			j += 1
+		}
+	}
+
+	return int(lengths[0])
+}
+
+// Merges consecutive changed tokens into ranges
+func tokenRanges(tokens []lineToken, changed []bool) (result []GitTextRange) {
+	for index, token := range tokens {
+		if !changed[index] {
+			continue
+		}
+
+		end := token.start + len(token.text)
+		if len(result) > 0 && result[len(result)-1].End == token.start {
+			result[len(result)-1].End = end
+		} else {
+			result = append(result, GitTextRange{Start: token.start, End: end})
+		}
+	}
+
+	return
+}
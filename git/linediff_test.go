@@ -0,0 +1,65 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLineRanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldText     string
+		newText     string
+		granularity GitDiffGranularity
+		oldRanges   []GitTextRange
+		newRanges   []GitTextRange
+	}{
+		{"identical", "same line", "same line", GIT_DIFF_WORDS, nil, nil},
+		{"insertion", "foo(a)", "foo(a, b)", GIT_DIFF_WORDS, nil, []GitTextRange{{5, 8}}},
+		{"deletion", "foo(a, b)", "foo(a)", GIT_DIFF_WORDS, []GitTextRange{{5, 8}}, nil},
+		{"middle of a word by words", "counter += 1", "countor += 1", GIT_DIFF_WORDS, []GitTextRange{{0, 7}}, []GitTextRange{{0, 7}}},
+		{"middle of a word by characters", "counter += 1", "countor += 1", GIT_DIFF_CHARACTERS, []GitTextRange{{5, 6}}, []GitTextRange{{5, 6}}},
+		{"separate changes", "a = b + c", "x = b + z", GIT_DIFF_WORDS, []GitTextRange{{0, 1}, {8, 9}}, []GitTextRange{{0, 1}, {8, 9}}},
+		{"multibyte by words", "größe = 1", "grösse = 1", GIT_DIFF_WORDS, []GitTextRange{{0, 7}}, []GitTextRange{{0, 7}}},
+		{"multibyte by characters", "größe = 1", "grösse = 1", GIT_DIFF_CHARACTERS, []GitTextRange{{4, 6}}, []GitTextRange{{4, 6}}},
+		{"nothing in common", "abc", "xyz", GIT_DIFF_CHARACTERS, nil, nil},
+		// Too long to compare token by token, so everything between the common ends is changed
+		{"over the cap", "X" + strings.Repeat("ab", 300) + "Y", "X" + strings.Repeat("ba", 300) + "Y", GIT_DIFF_CHARACTERS, []GitTextRange{{1, 601}}, []GitTextRange{{1, 601}}},
+		{"under the cap", "X" + strings.Repeat("ab", 3) + "Y", "X" + strings.Repeat("ba", 3) + "Y", GIT_DIFF_CHARACTERS, []GitTextRange{{1, 2}}, []GitTextRange{{6, 7}}},
+	}
+
+	for _, test := range tests {
+		oldRanges, newRanges := DiffLineRanges(test.oldText, test.newText, test.granularity)
+		if !reflect.DeepEqual(oldRanges, test.oldRanges) || !reflect.DeepEqual(newRanges, test.newRanges) {
+			t.Errorf("%s: got %v and %v, want %v and %v", test.name, oldRanges, newRanges, test.oldRanges, test.newRanges)
+		}
+	}
+}
+
+func TestMarkChangedRanges(t *testing.T) {
+	diff := GitDiff{
+		OldChunks: []GitDiffFile{{Lines: []GitDiffLine{
+			{Text: "a b", Type: GIT_LINE_REMOVED},
+			{Text: "same", Type: GIT_LINE_UNMODIFIED, Changes: []GitTextRange{{0, 1}}},
+			{Text: "removed", Type: GIT_LINE_REMOVED},
+		}}},
+		NewChunks: []GitDiffFile{{Lines: []GitDiffLine{
+			{Text: "a c", Type: GIT_LINE_NEW},
+			{Text: "same", Type: GIT_LINE_UNMODIFIED},
+			{Type: GIT_LINE_EMPTY},
+		}}},
+	}
+
+	diff.MarkChangedRanges(GIT_DIFF_WORDS)
+
+	oldLines, newLines := diff.OldChunks[0].Lines, diff.NewChunks[0].Lines
+	if want := []GitTextRange{{2, 3}}; !reflect.DeepEqual(oldLines[0].Changes, want) || !reflect.DeepEqual(newLines[0].Changes, want) {
+		t.Errorf("paired lines: got %v and %v", oldLines[0].Changes, newLines[0].Changes)
+	}
+	for row := 1; row < 3; row += 1 {
+		if oldLines[row].Changes != nil || newLines[row].Changes != nil {
+			t.Errorf("row %d without a pair has changes %v and %v", row, oldLines[row].Changes, newLines[row].Changes)
+		}
+	}
+}
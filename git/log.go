@@ -0,0 +1,128 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Number of NUL terminated fields that logFormat prints for every commit
+const logFormatFields = 8
+
+// Reads the history from a single `git log` that is kept running between pages. Paging with
+// --skip would make git walk every skipped commit again for every page.
+type GitLogReader struct {
+	command []string
+	cmd     *exec.Cmd
+	stdout  io.ReadCloser
+	stderr  bytes.Buffer
+	reader  *bufio.Reader
+	done    bool
+}
+
+func StartLog(pathToRepo string) (result *GitLogReader, err error) {
+	// The graph lays out lanes assuming that children come before their parents, which commit
+	// dates don't guarantee. The price is that git sorts the whole history before printing the
+	// first commit unless the repository has a commit-graph file, which `git gc` writes by default.
+	// --date-order sorts the same way. For 200k commits that took 1.4s instead of 10ms.
+	result = &GitLogReader{command: []string{"log", "--topo-order", logFormat}}
+	if !hasHead(pathToRepo) {
+		result.done = true
+		return
+	}
+
+	result.cmd = exec.Command("git", result.command...)
+	result.cmd.Dir = pathToRepo
+	result.cmd.Env = gitEnvironment(nil)
+	result.cmd.Stderr = &result.stderr
+
+	result.stdout, err = result.cmd.StdoutPipe()
+	if err != nil {
+		return nil, newGitError(result.command, err, "", "")
+	}
+
+	err = result.cmd.Start()
+	if err != nil {
+		return nil, newGitError(result.command, err, "", "")
+	}
+
+	result.reader = bufio.NewReader(result.stdout)
+
+	return
+}
+
+// Returns up to count of the next commits, fewer once the end of the history is reached
+func (log *GitLogReader) Next(count int) (result []GitCommit, err error) {
+	if log.done {
+		return
+	}
+
+	var sb strings.Builder
+	read := 0
+	for read < count*logFormatFields {
+		field, readErr := log.reader.ReadString(0)
+		if readErr != nil {
+			log.done = true
+
+			// Whatever is left without a terminating NUL is the newline after the last commit
+			if errors.Is(readErr, io.EOF) {
+				err = log.wait()
+			} else {
+				log.Close()
+				err = newGitError(log.command, readErr, "", log.stderr.String())
+			}
+
+			break
+		}
+
+		sb.WriteString(field)
+		read += 1
+	}
+
+	return ParseLog(sb.String()), err
+}
+
+// Upper bound for the position of the commit in the history that StartLog reads. In topological
+// order, only commits that the commit can't reach can come before it.
+func CountCommitsBefore(hash string, pathToRepo string) (int, error) {
+	output, err := executeGit([]string{"rev-list", "--count", hash + "..HEAD"}, pathToRepo)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(output))
+}
+
+// Whether ancestor is descendant or one of the commits it was built on
+func IsAncestor(ancestor string, descendant string, pathToRepo string) bool {
+	_, err := executeGit([]string{"merge-base", "--is-ancestor", ancestor, descendant}, pathToRepo)
+	return err == nil
+}
+
+func (log *GitLogReader) Done() bool {
+	return log.done
+}
+
+// Stops git if the history hasn't been read to the end
+func (log *GitLogReader) Close() {
+	if log.cmd == nil || log.cmd.ProcessState != nil {
+		return
+	}
+
+	log.done = true
+	log.cmd.Process.Kill()
+	log.cmd.Wait()
+}
+
+func (log *GitLogReader) wait() error {
+	err := log.cmd.Wait()
+	if err != nil {
+		return newGitError(log.command, err, "", log.stderr.String())
+	}
+
+	return nil
+}
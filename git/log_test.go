@@ -0,0 +1,175 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLogReaderPages(t *testing.T) {
+	dir := newTestRepo(t)
+	for index := 1; index <= 7; index += 1 {
+		commitTestFile(t, dir, "file.txt", strconv.Itoa(index)+"\n", "Commit "+strconv.Itoa(index))
+	}
+
+	reader, err := StartLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var subjects []string
+	for _, want := range []int{3, 3, 1} {
+		commits, err := reader.Next(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commits) != want {
+			t.Fatalf("page has %d commits, want %d", len(commits), want)
+		}
+
+		for _, commit := range commits {
+			subjects = append(subjects, commit.Subject)
+		}
+	}
+
+	if !reader.Done() {
+		t.Error("reader is not done after the last commit")
+	}
+	if commits, err := reader.Next(3); len(commits) != 0 || err != nil {
+		t.Errorf("reading past the end returned %d commits and %v", len(commits), err)
+	}
+
+	expectTestLines(t, "subjects", subjects, "Commit 7", "Commit 6", "Commit 5", "Commit 4", "Commit 3", "Commit 2", "Commit 1")
+}
+
+func TestLogReaderCloseStopsGit(t *testing.T) {
+	dir := newTestRepo(t)
+	for index := 1; index <= 3; index += 1 {
+		commitTestFile(t, dir, "file.txt", strconv.Itoa(index)+"\n", "Commit "+strconv.Itoa(index))
+	}
+
+	reader, err := StartLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if commits, err := reader.Next(1); len(commits) != 1 || err != nil {
+		t.Fatalf("got %d commits and %v", len(commits), err)
+	}
+
+	reader.Close()
+	if !reader.Done() {
+		t.Error("reader is not done after closing it")
+	}
+}
+
+func TestLogReaderWithoutCommits(t *testing.T) {
+	reader, err := StartLog(newTestRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if commits, err := reader.Next(10); len(commits) != 0 || err != nil || !reader.Done() {
+		t.Errorf("got %d commits, %v, done %v", len(commits), err, reader.Done())
+	}
+}
+
+func commitTestFileAt(t *testing.T, dir string, date string, name string, contents string, message string) {
+	t.Helper()
+
+	writeTestFile(t, dir, name, contents)
+	runTestGit(t, dir, "add", "--", name)
+
+	env := []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}
+	if _, err := executeGitWithEnv([]string{"commit", "-q", "-m", message}, env, dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The graph needs every commit to come before its parents, which commit dates alone don't give
+func TestLogReaderListsChildrenBeforeParents(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFileAt(t, dir, "2020-01-01T00:00:00Z", "file.txt", "root\n", "Root")
+	commitTestFileAt(t, dir, "2025-01-01T00:00:00Z", "file.txt", "parent\n", "Parent")
+
+	// A child that claims to be older than its parent, next to a newer one on main
+	runTestGit(t, dir, "checkout", "-q", "-b", "skewed")
+	commitTestFileAt(t, dir, "2000-01-01T00:00:00Z", "skewed.txt", "skewed\n", "Skewed")
+	runTestGit(t, dir, "checkout", "-q", "main")
+	commitTestFileAt(t, dir, "2030-01-01T00:00:00Z", "file.txt", "main\n", "Main")
+
+	env := []string{"GIT_AUTHOR_DATE=2031-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2031-01-01T00:00:00Z"}
+	if _, err := executeGitWithEnv([]string{"merge", "-q", "--no-ff", "-m", "Merge", "skewed"}, env, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := StartLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	commits, err := reader.Next(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for _, commit := range commits {
+		for _, parent := range commit.Parents {
+			if seen[parent] {
+				t.Errorf("%s comes after its parent %s", commit.Subject, parent)
+			}
+		}
+
+		seen[commit.Hash] = true
+	}
+}
+
+// Jumping to a commit loads CountCommitsBefore + 1 commits, which has to include it
+func TestCountCommitsBeforeCoversPosition(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "root\n", "Root")
+	runTestGit(t, dir, "checkout", "-q", "-b", "side")
+	commitTestFile(t, dir, "side.txt", "1\n", "Side 1")
+	commitTestFile(t, dir, "side.txt", "2\n", "Side 2")
+	runTestGit(t, dir, "checkout", "-q", "main")
+	commitTestFile(t, dir, "file.txt", "main\n", "Main")
+	runTestGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge", "side")
+	commitTestFile(t, dir, "file.txt", "last\n", "Last")
+
+	reader, err := StartLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	commits, err := reader.Next(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for position, commit := range commits {
+		if !IsAncestor(commit.Hash, "HEAD", dir) {
+			t.Errorf("%s is not an ancestor of HEAD", commit.Subject)
+		}
+
+		before, err := CountCommitsBefore(commit.Hash, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if position > before {
+			t.Errorf("%s is at %d, but only %d commits were counted before it", commit.Subject, position, before)
+		}
+	}
+
+	runTestGit(t, dir, "checkout", "-q", "-b", "unmerged")
+	commitTestFile(t, dir, "file.txt", "unmerged\n", "Unmerged")
+	unmerged := strings.TrimSpace(runTestGit(t, dir, "rev-parse", "HEAD"))
+	runTestGit(t, dir, "checkout", "-q", "main")
+
+	if IsAncestor(unmerged, "HEAD", dir) {
+		t.Error("a commit on another branch is an ancestor of HEAD")
+	}
+}
@@ -0,0 +1,166 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type GitMergeStrategy uint8
+type GitOperation uint8
+
+const (
+	GIT_MERGE_FAST_FORWARD GitMergeStrategy = iota
+	GIT_MERGE_NO_FAST_FORWARD
+	GIT_MERGE_SQUASH
+)
+
+const (
+	GIT_OPERATION_NONE GitOperation = iota
+	GIT_OPERATION_MERGE
+	GIT_OPERATION_REBASE
+	GIT_OPERATION_CHERRY_PICK
+	GIT_OPERATION_REVERT
+)
+
+// What the repository is in the middle of, if anything
+type GitOperationState struct {
+	Operation GitOperation
+	HeadName  string // Branch being rebased, empty for merges and detached rebases
+	StoppedAt string // Commit a cherry-pick or revert stopped at
+
+	// Progress of a rebase, both are 0 when unknown
+	Step  int
+	Total int
+
+	// Set when an interactive rebase stopped at a `break`, along with the original hash of
+	// the commit that was made right before it
+	StoppedAtBreak bool
+	LastRewritten  string
+}
+
+// Continuing must never stop and wait for someone to edit a message in a terminal
+var nonInteractiveEditor = []string{"GIT_EDITOR=true"}
+
+func Merge(branchName string, strategy GitMergeStrategy, pathToRepo string) error {
+	command := []string{"merge"}
+	switch strategy {
+	case GIT_MERGE_FAST_FORWARD:
+		command = append(command, "--ff-only")
+	case GIT_MERGE_NO_FAST_FORWARD:
+		command = append(command, "--no-ff", "--no-edit")
+	case GIT_MERGE_SQUASH:
+		command = append(command, "--squash")
+	}
+
+	_, err := executeGitWithEnv(append(command, branchName), nonInteractiveEditor, pathToRepo)
+	return err
+}
+
+func Rebase(branchName string, pathToRepo string) error {
+	_, err := executeGitWithEnv([]string{"rebase", branchName}, nonInteractiveEditor, pathToRepo)
+	return err
+}
+
+func GetOperationState(pathToRepo string) (result GitOperationState, err error) {
+	output, err := executeGit([]string{"rev-parse", "--absolute-git-dir"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	gitDir := strings.TrimSpace(output)
+
+	for _, rebaseDir := range []string{"rebase-merge", "rebase-apply"} {
+		statePath := filepath.Join(gitDir, rebaseDir)
+		if !isDirectory(statePath) {
+			continue
+		}
+
+		result.Operation = GIT_OPERATION_REBASE
+		result.HeadName = strings.TrimPrefix(readStateFile(filepath.Join(statePath, "head-name")), "refs/heads/")
+
+		// rebase-apply names its progress files differently
+		if rebaseDir == "rebase-merge" {
+			result.Step, _ = strconv.Atoi(readStateFile(filepath.Join(statePath, "msgnum")))
+			result.Total, _ = strconv.Atoi(readStateFile(filepath.Join(statePath, "end")))
+
+			// rewritten-list misses commits that git could keep as they were, the step before the
+			// break in the done list always names it
+			done := strings.Split(readStateFile(filepath.Join(statePath, "done")), "\n")
+			result.StoppedAtBreak = strings.TrimSpace(done[len(done)-1]) == "break"
+			if result.StoppedAtBreak && len(done) > 1 {
+				if fields := strings.Fields(done[len(done)-2]); len(fields) > 1 {
+					result.LastRewritten = fields[1]
+				}
+			}
+		} else {
+			result.Step, _ = strconv.Atoi(readStateFile(filepath.Join(statePath, "next")))
+			result.Total, _ = strconv.Atoi(readStateFile(filepath.Join(statePath, "last")))
+		}
+
+		return
+	}
+
+	if _, statErr := os.Stat(filepath.Join(gitDir, "MERGE_HEAD")); statErr == nil {
+		result.Operation = GIT_OPERATION_MERGE
+	} else if stoppedAt := readStateFile(filepath.Join(gitDir, "CHERRY_PICK_HEAD")); stoppedAt != "" {
+		result.Operation = GIT_OPERATION_CHERRY_PICK
+		result.StoppedAt = stoppedAt
+	} else if stoppedAt := readStateFile(filepath.Join(gitDir, "REVERT_HEAD")); stoppedAt != "" {
+		result.Operation = GIT_OPERATION_REVERT
+		result.StoppedAt = stoppedAt
+	}
+
+	return
+}
+
+func ContinueOperation(operation GitOperation, pathToRepo string) error {
+	return runOperationCommand(operation, "--continue", pathToRepo)
+}
+
+func AbortOperation(operation GitOperation, pathToRepo string) error {
+	return runOperationCommand(operation, "--abort", pathToRepo)
+}
+
+// Merges have no commit to skip
+func SkipOperation(operation GitOperation, pathToRepo string) error {
+	if operation == GIT_OPERATION_MERGE {
+		return nil
+	}
+
+	return runOperationCommand(operation, "--skip", pathToRepo)
+}
+
+func runOperationCommand(operation GitOperation, action string, pathToRepo string) error {
+	var command string
+	switch operation {
+	case GIT_OPERATION_MERGE:
+		command = "merge"
+	case GIT_OPERATION_REBASE:
+		command = "rebase"
+	case GIT_OPERATION_CHERRY_PICK:
+		command = "cherry-pick"
+	case GIT_OPERATION_REVERT:
+		command = "revert"
+	default:
+		return nil
+	}
+
+	_, err := executeGitWithEnv([]string{command, action}, nonInteractiveEditor, pathToRepo)
+	return err
+}
+
+func readStateFile(path string) string {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(contents))
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+func StageHunk(entry GitStatusEntry, diff GitDiff, hunk int, pathToRepo string) error {
+	// There is nothing in the index to apply an untracked file's hunk to, so take the whole file
+	if entry.Type == GIT_ENTRY_NEW_UNSTAGED {
+		return Stage(entry, pathToRepo)
+	}
+
+	return applyPatch(BuildHunkPatch(diff, hunk), []string{"--cached"}, diff.Options, pathToRepo)
+}
+
+func UnstageHunk(entry GitStatusEntry, diff GitDiff, hunk int, pathToRepo string) error {
+	return applyPatch(BuildHunkPatch(diff, hunk), []string{"--cached", "-R"}, diff.Options, pathToRepo)
+}
+
+func DiscardHunk(entry GitStatusEntry, diff GitDiff, hunk int, pathToRepo string) error {
+	return discardPatch(entry, BuildHunkPatch(diff, hunk), diff.Options, pathToRepo)
+}
+
+// Produces a patch that contains the file header and only the given hunk of the diff
+func BuildHunkPatch(diff GitDiff, hunk int) string {
+	var sb strings.Builder
+
+	for _, line := range diff.Header {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+
+	for _, line := range diff.RawChunks[hunk] {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+func applyPatch(patch string, options []string, diffOptions GitDiffOptions, pathToRepo string) error {
+	command := append([]string{"apply", "--recount"}, options...)
+	command = append(command, diffOptions.applyArgs()...)
+	command = append(command, "-")
+
+	_, err := executeGitWithInput(command, patch, pathToRepo)
+	return err
+}
+
+func StageLines(entry GitStatusEntry, diff GitDiff, hunk int, lines []int, pathToRepo string) error {
+	// `git apply --cached` needs the file to be in the index, intent-to-add puts an empty placeholder there
+	if entry.Type == GIT_ENTRY_NEW_UNSTAGED {
+		_, err := executeGit([]string{"add", "-N", "--", entry.Filename}, pathToRepo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return applyPatch(BuildLinesPatch(diff, hunk, lines, false), []string{"--cached"}, diff.Options, pathToRepo)
+}
+
+func UnstageLines(entry GitStatusEntry, diff GitDiff, hunk int, lines []int, pathToRepo string) error {
+	return applyPatch(BuildLinesPatch(diff, hunk, lines, true), []string{"--cached", "-R"}, diff.Options, pathToRepo)
+}
+
+func DiscardLines(entry GitStatusEntry, diff GitDiff, hunk int, lines []int, pathToRepo string) error {
+	return discardPatch(entry, BuildLinesPatch(diff, hunk, lines, true), diff.Options, pathToRepo)
+}
+
+// A staged change is taken out of the index and the worktree one after the other, `--index` would
+// refuse to do it whenever the worktree differs from the index. The worktree is checked first, so
+// that the change is never left removed from the index only.
+func discardPatch(entry GitStatusEntry, patch string, diffOptions GitDiffOptions, pathToRepo string) error {
+	if !entry.Staged {
+		return applyPatch(patch, []string{"-R"}, diffOptions, pathToRepo)
+	}
+
+	err := applyPatch(patch, []string{"-R", "--check"}, diffOptions, pathToRepo)
+	if err != nil {
+		return fmt.Errorf("%s has unstaged changes next to the staged ones, discard or stage them first: %w", entry.Filename, err)
+	}
+
+	err = applyPatch(patch, []string{"--cached", "-R"}, diffOptions, pathToRepo)
+	if err != nil {
+		return err
+	}
+
+	return applyPatch(patch, []string{"-R"}, diffOptions, pathToRepo)
+}
+
+// Produces a patch that only contains the selected added and removed lines of a hunk. Lines are
+// indices into diff.RawChunks[hunk]. This is what `git add -p` does in its edit mode: unselected
+// removals become context and unselected additions are dropped. A patch that is going to be applied
+// in reverse needs the opposite, because the side that already contains the additions is the one
+// being patched.
+func BuildLinesPatch(diff GitDiff, hunk int, lines []int, reverse bool) string {
+	selected := make(map[int]bool)
+	for _, line := range lines {
+		selected[line] = true
+	}
+
+	raw := diff.RawChunks[hunk]
+	oldStart, _, newStart, _ := parseChunkRange(raw[0])
+
+	body := make([]string, 0)
+	var oldCount uint32 = 0
+	var newCount uint32 = 0
+	partial := false
+	lastKept := false
+
+	for index := 1; index < len(raw); index += 1 {
+		line := raw[index]
+
+		if strings.HasPrefix(line, "\\") {
+			if lastKept {
+				body = append(body, line)
+			}
+
+			continue
+		}
+
+		lastKept = true
+
+		if strings.HasPrefix(line, "+") {
+			if selected[index] {
+				body = append(body, line)
+				newCount += 1
+			} else if reverse {
+				body = append(body, " "+line[1:])
+				oldCount += 1
+				newCount += 1
+				partial = true
+			} else {
+				lastKept = false
+				partial = true
+			}
+		} else if strings.HasPrefix(line, "-") {
+			if selected[index] {
+				body = append(body, line)
+				oldCount += 1
+			} else if !reverse {
+				body = append(body, " "+line[1:])
+				oldCount += 1
+				newCount += 1
+				partial = true
+			} else {
+				lastKept = false
+				partial = true
+			}
+		} else {
+			body = append(body, line)
+			oldCount += 1
+			newCount += 1
+		}
+	}
+
+	// A range that starts at line 0 is only valid while it's empty
+	if oldStart == 0 && oldCount > 0 {
+		oldStart = 1
+	}
+	if newStart == 0 && newCount > 0 {
+		newStart = 1
+	}
+
+	var sb strings.Builder
+
+	// Reversing part of a deletion brings the file back with only the selected lines, so that patch
+	// still deletes the whole file and keeps its header
+	header := diff.Header
+	if partial && !(reverse && newCount == 0 && isDeletionHeader(diff.Header)) {
+		header = modificationHeader(diff.Header)
+	}
+
+	for _, line := range header {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+
+	sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+
+	for _, line := range body {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+func isDeletionHeader(header []string) bool {
+	for _, line := range header {
+		if strings.HasPrefix(line, "deleted file mode") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Only part of a new or deleted file is being applied, so the file is neither created nor
+// removed by the patch and the header has to describe a plain modification instead
+func modificationHeader(header []string) (result []string) {
+	path := ""
+	for _, line := range header {
+		if strings.HasPrefix(line, "+++ b/") {
+			path = strings.TrimPrefix(line, "+++ b/")
+		} else if strings.HasPrefix(line, "--- a/") {
+			path = strings.TrimPrefix(line, "--- a/")
+		}
+	}
+
+	for _, line := range header {
+		if strings.HasPrefix(line, "new file mode") || strings.HasPrefix(line, "deleted file mode") {
+			continue
+		} else if line == "--- /dev/null" {
+			result = append(result, "--- a/"+path)
+		} else if line == "+++ /dev/null" {
+			result = append(result, "+++ b/"+path)
+		} else {
+			result = append(result, line)
+		}
+	}
+
+	return
+}
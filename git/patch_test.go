@@ -0,0 +1,260 @@
+package git
+
+import "testing"
+
+const patchTestBase = "one\ntwo\nthree\nfour\nfive\n"
+const patchTestChanged = "one\nTWO\nthree\nfour\nfour and a half\nfive\n"
+
+func newModifiedTestRepo(t *testing.T) string {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", patchTestBase, "Add file")
+	writeTestFile(t, dir, "file.txt", patchTestChanged)
+
+	return dir
+}
+
+func diffTestEntry(t *testing.T, dir string, entry GitStatusEntry) GitDiff {
+	t.Helper()
+
+	diff, err := DiffEntry(entry, GitDiffOptions{}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return diff
+}
+
+func TestStageLinesModified(t *testing.T) {
+	dir := newModifiedTestRepo(t)
+
+	entry := findTestEntry(t, dir, "file.txt", false)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := StageLines(entry, diff, 0, rawTestIndices(t, diff, "-two", "+TWO"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"-two", "+TWO"}, []string{"+four and a half"})
+}
+
+func TestStageLinesAdditionWithoutRemoval(t *testing.T) {
+	dir := newModifiedTestRepo(t)
+
+	entry := findTestEntry(t, dir, "file.txt", false)
+	diff := diffTestEntry(t, dir, entry)
+
+	// The unselected removal stays in the index as context
+	if err := StageLines(entry, diff, 0, rawTestIndices(t, diff, "+TWO"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"+TWO"}, []string{"-two", "+four and a half"})
+}
+
+func TestUnstageLinesModified(t *testing.T) {
+	dir := newModifiedTestRepo(t)
+	runTestGit(t, dir, "add", "file.txt")
+
+	entry := findTestEntry(t, dir, "file.txt", true)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := UnstageLines(entry, diff, 0, rawTestIndices(t, diff, "+four and a half"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"-two", "+TWO"}, []string{"+four and a half"})
+}
+
+func TestUnstageLinesRemovalWithoutAddition(t *testing.T) {
+	dir := newModifiedTestRepo(t)
+	runTestGit(t, dir, "add", "file.txt")
+
+	entry := findTestEntry(t, dir, "file.txt", true)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := UnstageLines(entry, diff, 0, rawTestIndices(t, diff, "-two"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"+TWO", "+four and a half"}, []string{"-two"})
+}
+
+func TestDiscardLinesModified(t *testing.T) {
+	dir := newModifiedTestRepo(t)
+
+	entry := findTestEntry(t, dir, "file.txt", false)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := DiscardLines(entry, diff, 0, rawTestIndices(t, diff, "+four and a half"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, nil, []string{"-two", "+TWO"})
+	if contents := readTestFile(t, dir, "file.txt"); contents != "one\nTWO\nthree\nfour\nfive\n" {
+		t.Errorf("worktree file is %q", contents)
+	}
+}
+
+func newUntrackedTestRepo(t *testing.T) string {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "other.txt", "other\n", "Add other file")
+	writeTestFile(t, dir, "new.txt", "a\nb\nc\n")
+
+	return dir
+}
+
+func TestStageLinesNewFile(t *testing.T) {
+	dir := newUntrackedTestRepo(t)
+
+	entry := findTestEntry(t, dir, "new.txt", false)
+	if entry.Type != GIT_ENTRY_NEW_UNSTAGED {
+		t.Fatalf("entry type is %v", entry.Type)
+	}
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := StageLines(entry, diff, 0, rawTestIndices(t, diff, "+a", "+c"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"+a", "+c"}, []string{"+b"})
+}
+
+func TestUnstageLinesNewFile(t *testing.T) {
+	dir := newUntrackedTestRepo(t)
+	runTestGit(t, dir, "add", "new.txt")
+
+	entry := findTestEntry(t, dir, "new.txt", true)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := UnstageLines(entry, diff, 0, rawTestIndices(t, diff, "+b"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"+a", "+c"}, []string{"+b"})
+}
+
+func TestDiscardLinesNewFile(t *testing.T) {
+	dir := newUntrackedTestRepo(t)
+
+	entry := findTestEntry(t, dir, "new.txt", false)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := DiscardLines(entry, diff, 0, rawTestIndices(t, diff, "+b"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if contents := readTestFile(t, dir, "new.txt"); contents != "a\nc\n" {
+		t.Errorf("worktree file is %q", contents)
+	}
+	expectTestDiffs(t, dir, nil, nil)
+}
+
+func newDeletedTestRepo(t *testing.T) string {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "old.txt", "x\ny\nz\n", "Add old file")
+
+	return dir
+}
+
+func TestStageLinesDeletedFile(t *testing.T) {
+	dir := newDeletedTestRepo(t)
+	removeTestFile(t, dir, "old.txt")
+
+	entry := findTestEntry(t, dir, "old.txt", false)
+	if entry.Type != GIT_ENTRY_DELETED {
+		t.Fatalf("entry type is %v", entry.Type)
+	}
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := StageLines(entry, diff, 0, rawTestIndices(t, diff, "-x"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"-x"}, []string{"-y", "-z"})
+}
+
+func TestUnstageLinesDeletedFile(t *testing.T) {
+	dir := newDeletedTestRepo(t)
+	runTestGit(t, dir, "rm", "-q", "old.txt")
+
+	entry := findTestEntry(t, dir, "old.txt", true)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := UnstageLines(entry, diff, 0, rawTestIndices(t, diff, "-y"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"-x", "-z"}, []string{"-y"})
+}
+
+func TestDiscardLinesDeletedFile(t *testing.T) {
+	dir := newDeletedTestRepo(t)
+	removeTestFile(t, dir, "old.txt")
+
+	entry := findTestEntry(t, dir, "old.txt", false)
+	diff := diffTestEntry(t, dir, entry)
+
+	// Bringing back some of the lines brings back the file with only those lines
+	if err := DiscardLines(entry, diff, 0, rawTestIndices(t, diff, "-y"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if contents := readTestFile(t, dir, "old.txt"); contents != "y\n" {
+		t.Errorf("worktree file is %q", contents)
+	}
+	expectTestDiffs(t, dir, nil, []string{"-x", "-z"})
+}
+
+func TestDiscardHunkStagedWithUnstagedChanges(t *testing.T) {
+	dir := newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "Add file")
+	writeTestFile(t, dir, "file.txt", "ONE\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+	runTestGit(t, dir, "add", "file.txt")
+	writeTestFile(t, dir, "file.txt", "ONE\n2\n3\n4\n5\n6\n7\n8\n9\nTEN\n")
+
+	entry := findTestEntry(t, dir, "file.txt", true)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := DiscardHunk(entry, diff, 0, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, nil, []string{"-10", "+TEN"})
+	if contents := readTestFile(t, dir, "file.txt"); contents != "1\n2\n3\n4\n5\n6\n7\n8\n9\nTEN\n" {
+		t.Errorf("worktree file is %q", contents)
+	}
+}
+
+func TestDiscardLinesStagedWithUnstagedChanges(t *testing.T) {
+	dir := newModifiedTestRepo(t)
+	runTestGit(t, dir, "add", "file.txt")
+	writeTestFile(t, dir, "file.txt", patchTestChanged+"six\n")
+
+	entry := findTestEntry(t, dir, "file.txt", true)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := DiscardLines(entry, diff, 0, rawTestIndices(t, diff, "-two", "+TWO"), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestDiffs(t, dir, []string{"+four and a half"}, []string{"+six"})
+	if contents := readTestFile(t, dir, "file.txt"); contents != "one\ntwo\nthree\nfour\nfour and a half\nfive\nsix\n" {
+		t.Errorf("worktree file is %q", contents)
+	}
+}
+
+// When the worktree changed the same lines again, discarding would have to throw those changes away too
+func TestDiscardHunkStagedRefusesOverlappingChanges(t *testing.T) {
+	dir := newModifiedTestRepo(t)
+	runTestGit(t, dir, "add", "file.txt")
+	writeTestFile(t, dir, "file.txt", "one\nTwo\nthree\nfour\nfour and a half\nfive\n")
+
+	entry := findTestEntry(t, dir, "file.txt", true)
+	diff := diffTestEntry(t, dir, entry)
+
+	if err := DiscardHunk(entry, diff, 0, dir); err == nil {
+		t.Fatal("discarding a staged hunk that the worktree changed again succeeded")
+	}
+
+	expectTestDiffs(t, dir, []string{"-two", "+TWO", "+four and a half"}, []string{"-TWO", "+Two"})
+}
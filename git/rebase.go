@@ -0,0 +1,140 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type GitRebaseAction uint8
+
+const (
+	GIT_REBASE_PICK GitRebaseAction = iota
+	GIT_REBASE_REWORD
+	GIT_REBASE_EDIT
+	GIT_REBASE_SQUASH
+	GIT_REBASE_FIXUP
+	GIT_REBASE_DROP
+)
+
+// One line of an interactive rebase todo list
+type GitRebaseStep struct {
+	Action GitRebaseAction
+	Commit GitCommit
+}
+
+// Lists the commits that an interactive rebase onto base would replay, oldest first like in a todo list
+func ListRebaseCommits(base string, pathToRepo string) (result []GitCommit, err error) {
+	output, err := executeGit([]string{"log", "--reverse", "--no-merges", "--topo-order", logFormat, base + "..HEAD"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	result = ParseLog(output)
+
+	return
+}
+
+// Runs `git rebase -i` with the given steps instead of asking for a todo list. Rewords are turned
+// into a pick followed by a break, so the rebase stops right after the commit was made even if it
+// had conflicts, see AmendCommitSubject.
+func InteractiveRebase(base string, steps []GitRebaseStep, pathToRepo string) error {
+	err := validateRebaseSteps(steps)
+	if err != nil {
+		return err
+	}
+
+	todo, err := os.CreateTemp("", "gitgud-rebase-todo")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(todo.Name())
+
+	_, err = todo.WriteString(buildRebaseTodo(steps))
+	todo.Close()
+	if err != nil {
+		return err
+	}
+
+	// Git runs the sequence editor through a shell with the path to its own todo list appended
+	sequenceEditor := fmt.Sprintf("GIT_SEQUENCE_EDITOR=cp '%s'", filepath.ToSlash(todo.Name()))
+
+	_, err = executeGitWithEnv([]string{"rebase", "-i", base}, append(nonInteractiveEditor, sequenceEditor), pathToRepo)
+	return err
+}
+
+// Replaces the first line of HEAD's message and keeps the rest of it
+func AmendCommitSubject(subject string, pathToRepo string) error {
+	message, err := replaceCommitSubject(subject, pathToRepo)
+	if err != nil {
+		return err
+	}
+
+	_, err = executeGitWithInput([]string{"commit", "--amend", "--only", "--no-verify", "-F", "-"}, message, pathToRepo)
+	return err
+}
+
+// HEAD's message with its first line replaced by subject
+func replaceCommitSubject(subject string, pathToRepo string) (string, error) {
+	message, err := executeGit([]string{"log", "-1", "--format=%B"}, pathToRepo)
+	if err != nil {
+		return "", err
+	}
+
+	body := ""
+	if index := strings.Index(message, "\n"); index >= 0 {
+		body = message[index:]
+	}
+
+	return subject + body, nil
+}
+
+func RebaseActionName(action GitRebaseAction) string {
+	switch action {
+	case GIT_REBASE_PICK:
+		return "pick"
+	case GIT_REBASE_REWORD:
+		return "reword"
+	case GIT_REBASE_EDIT:
+		return "edit"
+	case GIT_REBASE_SQUASH:
+		return "squash"
+	case GIT_REBASE_FIXUP:
+		return "fixup"
+	case GIT_REBASE_DROP:
+		return "drop"
+	default:
+		panic("Unreachable")
+	}
+}
+
+func buildRebaseTodo(steps []GitRebaseStep) string {
+	var builder strings.Builder
+	for _, step := range steps {
+		if step.Action == GIT_REBASE_REWORD {
+			builder.WriteString(fmt.Sprintf("pick %s\nbreak\n", step.Commit.Hash))
+		} else {
+			builder.WriteString(fmt.Sprintf("%s %s\n", RebaseActionName(step.Action), step.Commit.Hash))
+		}
+	}
+
+	return builder.String()
+}
+
+func validateRebaseSteps(steps []GitRebaseStep) error {
+	for _, step := range steps {
+		if step.Action == GIT_REBASE_DROP {
+			continue
+		}
+
+		if step.Action == GIT_REBASE_SQUASH || step.Action == GIT_REBASE_FIXUP {
+			return errors.New("the first commit that is kept cannot be squashed or fixed up, there is nothing before it")
+		}
+
+		return nil
+	}
+
+	return errors.New("every commit is dropped, nothing to rebase")
+}
@@ -0,0 +1,173 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+// main has Base, then A, B, C and D that each add their own file
+func newRebaseTestRepo(t *testing.T) (dir string, base string, commits []GitCommit) {
+	t.Helper()
+
+	dir = newTestRepo(t)
+	commitTestFile(t, dir, "base.txt", "base\n", "Base")
+	base = testHead(t, dir, "HEAD")
+
+	for _, name := range []string{"A", "B", "C", "D"} {
+		commitTestFile(t, dir, strings.ToLower(name)+".txt", name+"\n", name+"\n\nBody of "+name)
+	}
+
+	commits, err := ListRebaseCommits(base, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subjects []string
+	for _, commit := range commits {
+		subjects = append(subjects, commit.Subject)
+	}
+	expectTestLines(t, "rebase commits", subjects, "A", "B", "C", "D")
+
+	return
+}
+
+func rebaseTestSteps(commits []GitCommit, actions ...GitRebaseAction) (result []GitRebaseStep) {
+	for index, action := range actions {
+		result = append(result, GitRebaseStep{Action: action, Commit: commits[index]})
+	}
+
+	return
+}
+
+func TestBuildRebaseTodo(t *testing.T) {
+	commits := []GitCommit{{Hash: "aaa"}, {Hash: "bbb"}, {Hash: "ccc"}, {Hash: "ddd"}, {Hash: "eee"}}
+	steps := rebaseTestSteps(commits, GIT_REBASE_PICK, GIT_REBASE_REWORD, GIT_REBASE_SQUASH, GIT_REBASE_FIXUP, GIT_REBASE_DROP)
+
+	want := "pick aaa\npick bbb\nbreak\nsquash ccc\nfixup ddd\ndrop eee\n"
+	if todo := buildRebaseTodo(steps); todo != want {
+		t.Errorf("got todo %q, want %q", todo, want)
+	}
+}
+
+func TestValidateRebaseSteps(t *testing.T) {
+	commits := []GitCommit{{Hash: "aaa"}, {Hash: "bbb"}, {Hash: "ccc"}}
+
+	tests := []struct {
+		name    string
+		actions []GitRebaseAction
+		valid   bool
+	}{
+		{"pick everything", []GitRebaseAction{GIT_REBASE_PICK, GIT_REBASE_PICK, GIT_REBASE_PICK}, true},
+		{"squash after a pick", []GitRebaseAction{GIT_REBASE_PICK, GIT_REBASE_SQUASH, GIT_REBASE_FIXUP}, true},
+		{"squash after a dropped first commit", []GitRebaseAction{GIT_REBASE_DROP, GIT_REBASE_REWORD, GIT_REBASE_SQUASH}, true},
+		{"squash first", []GitRebaseAction{GIT_REBASE_SQUASH, GIT_REBASE_PICK, GIT_REBASE_PICK}, false},
+		{"fixup after dropping everything before it", []GitRebaseAction{GIT_REBASE_DROP, GIT_REBASE_FIXUP, GIT_REBASE_PICK}, false},
+		{"drop everything", []GitRebaseAction{GIT_REBASE_DROP, GIT_REBASE_DROP, GIT_REBASE_DROP}, false},
+	}
+
+	for _, test := range tests {
+		err := validateRebaseSteps(rebaseTestSteps(commits, test.actions...))
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+	}
+}
+
+func TestInteractiveRebaseRejectsInvalidStepsBeforeRunning(t *testing.T) {
+	dir, base, commits := newRebaseTestRepo(t)
+	head := testHead(t, dir, "HEAD")
+
+	for _, actions := range [][]GitRebaseAction{
+		{GIT_REBASE_SQUASH, GIT_REBASE_PICK, GIT_REBASE_PICK, GIT_REBASE_PICK},
+		{GIT_REBASE_DROP, GIT_REBASE_DROP, GIT_REBASE_DROP, GIT_REBASE_DROP},
+	} {
+		if err := InteractiveRebase(base, rebaseTestSteps(commits, actions...), dir); err == nil {
+			t.Errorf("rebase with %v was accepted", actions)
+		}
+
+		state, err := GetOperationState(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Operation != GIT_OPERATION_NONE {
+			t.Fatalf("a rebase was started for %v", actions)
+		}
+		if testHead(t, dir, "HEAD") != head {
+			t.Fatalf("HEAD moved for %v", actions)
+		}
+	}
+}
+
+func TestInteractiveRebaseReordersSquashesAndDrops(t *testing.T) {
+	dir, base, commits := newRebaseTestRepo(t)
+
+	// C moves to the front, B is squashed into A and D is dropped
+	steps := []GitRebaseStep{
+		{Action: GIT_REBASE_PICK, Commit: commits[2]},
+		{Action: GIT_REBASE_PICK, Commit: commits[0]},
+		{Action: GIT_REBASE_SQUASH, Commit: commits[1]},
+		{Action: GIT_REBASE_DROP, Commit: commits[3]},
+	}
+
+	if err := InteractiveRebase(base, steps, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTestLines(t, "subjects", testSubjects(t, dir, base+"..HEAD"), "A", "C")
+
+	message, err := GetLastCommitMessage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(message, "Body of A") || !strings.Contains(message, "Body of B") {
+		t.Errorf("squashed message is %q", message)
+	}
+
+	files := strings.Fields(runTestGit(t, dir, "ls-tree", "--name-only", "HEAD"))
+	expectTestLines(t, "files", files, "a.txt", "b.txt", "base.txt", "c.txt")
+}
+
+// A reword stops right after its commit was made, where the subject is replaced before going on.
+// Commits that git could keep as they were are covered as well as rewritten ones.
+func TestInteractiveRebaseStopsAfterReword(t *testing.T) {
+	for _, first := range []GitRebaseAction{GIT_REBASE_PICK, GIT_REBASE_DROP} {
+		dir, base, commits := newRebaseTestRepo(t)
+		steps := rebaseTestSteps(commits, first, GIT_REBASE_REWORD, GIT_REBASE_PICK, GIT_REBASE_PICK)
+
+		if err := InteractiveRebase(base, steps, dir); err != nil {
+			t.Fatal(err)
+		}
+
+		state, err := GetOperationState(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Operation != GIT_OPERATION_REBASE || !state.StoppedAtBreak {
+			t.Fatalf("rebase did not stop at the break: %+v", state)
+		}
+		if state.LastRewritten != commits[1].Hash {
+			t.Errorf("last rewritten commit is %q, want %s", state.LastRewritten, commits[1].Hash)
+		}
+
+		if err := AmendCommitSubject("B reworded", dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := ContinueOperation(GIT_OPERATION_REBASE, dir); err != nil {
+			t.Fatal(err)
+		}
+
+		if state, _ := GetOperationState(dir); state.Operation != GIT_OPERATION_NONE {
+			t.Fatalf("rebase is still in progress: %+v", state)
+		}
+
+		want := []string{"D", "C", "B reworded", "A"}
+		if first == GIT_REBASE_DROP {
+			want = want[:3]
+		}
+		expectTestLines(t, "subjects", testSubjects(t, dir, base+"..HEAD"), want...)
+
+		if message := runTestGit(t, dir, "log", "-1", "--format=%B", "HEAD~2"); !strings.Contains(message, "Body of B") {
+			t.Errorf("reworded message lost its body: %q", message)
+		}
+	}
+}
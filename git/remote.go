@@ -0,0 +1,234 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type GitProgress struct {
+	Phase   string
+	Percent int
+	Current int
+	Total   int
+}
+
+type GitPushOptions struct {
+	SetUpstream    bool
+	ForceWithLease bool
+}
+
+// Fetches from the given remote, or from all remotes if remote is empty
+func Fetch(ctx context.Context, remote string, pathToRepo string, onProgress func(GitProgress)) error {
+	command := []string{"fetch", "--progress", "--prune"}
+	if remote == "" {
+		command = append(command, "--all")
+	} else {
+		command = append(command, remote)
+	}
+
+	_, err := executeGitWithProgress(ctx, command, pathToRepo, onProgress)
+	return err
+}
+
+// Whether to merge or rebase is left to git, which knows about pull.rebase, branch.<name>.rebase
+// and values like "merges"
+func Pull(ctx context.Context, pathToRepo string, onProgress func(GitProgress)) error {
+	_, err := executeGitWithProgress(ctx, []string{"pull", "--progress"}, pathToRepo, onProgress)
+	return err
+}
+
+// Pushes the current branch. When the branch has no upstream yet and SetUpstream is on, it is
+// pushed to a branch with the same name on its push remote and that becomes its upstream.
+func Push(ctx context.Context, options GitPushOptions, pathToRepo string, onProgress func(GitProgress)) error {
+	command := []string{"push", "--progress"}
+	if options.ForceWithLease {
+		command = append(command, "--force-with-lease")
+	}
+
+	upstream, err := GetUpstream(pathToRepo)
+	if err != nil {
+		return err
+	}
+
+	if upstream == "" && options.SetUpstream {
+		branch, err := GetCurrentBranch(pathToRepo)
+		if err != nil {
+			return err
+		}
+
+		remote, err := PushRemote(branch, pathToRepo)
+		if err != nil {
+			return err
+		}
+
+		command = append(command, "--set-upstream", remote, branch)
+	}
+
+	_, err = executeGitWithProgress(ctx, command, pathToRepo, onProgress)
+	return err
+}
+
+// Clones url into destination. If the clone fails or is cancelled, whatever it managed to
+// create in destination is removed again.
+func Clone(ctx context.Context, url string, destination string, onProgress func(GitProgress)) error {
+	entries, statErr := os.ReadDir(destination)
+	existed := statErr == nil
+
+	_, err := executeGitWithProgress(ctx, []string{"clone", "--progress", url, destination}, "", onProgress)
+	if err != nil {
+		if !existed {
+			os.RemoveAll(destination)
+		} else if len(entries) == 0 {
+			// Git only clones into empty folders, so everything in it now came from the clone
+			leftovers, _ := os.ReadDir(destination)
+			for _, entry := range leftovers {
+				os.RemoveAll(filepath.Join(destination, entry.Name()))
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Returns the remote that git pushes the branch to, which is the only remote if none is configured
+func PushRemote(branch string, pathToRepo string) (string, error) {
+	for _, key := range []string{"branch." + branch + ".pushRemote", "remote.pushDefault", "branch." + branch + ".remote"} {
+		remote := GetConfig(key, pathToRepo)
+		if remote != "" && remote != "." {
+			return remote, nil
+		}
+	}
+
+	remotes, err := ListRemotes(pathToRepo)
+	if err != nil {
+		return "", err
+	}
+
+	if len(remotes) == 0 {
+		return "", errors.New("the repository has no remotes to push to")
+	} else if len(remotes) > 1 {
+		return "", errors.New("the repository has several remotes, set branch." + branch + ".pushRemote to the one to push to")
+	}
+
+	return remotes[0], nil
+}
+
+func ListRemotes(pathToRepo string) (result []string, err error) {
+	output, err := executeGit([]string{"remote"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return strings.Fields(output), nil
+}
+
+// Returns the upstream of the current branch, e.g. origin/master, or an empty string if there is none
+func GetUpstream(pathToRepo string) (string, error) {
+	output, err := executeGit([]string{"rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}"}, pathToRepo)
+	if err != nil {
+		if errors.Is(err, ErrNotARepository) {
+			return "", err
+		}
+
+		// Not having an upstream, a detached HEAD or an unborn branch are all the same for us
+		return "", nil
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// Counts the commits that the current branch has and its upstream doesn't, and the other way around
+func AheadBehind(pathToRepo string) (ahead int, behind int, err error) {
+	output, err := executeGit([]string{"rev-list", "--left-right", "--count", "HEAD...@{u}"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) == 2 {
+		ahead, _ = strconv.Atoi(fields[0])
+		behind, _ = strconv.Atoi(fields[1])
+	}
+
+	return
+}
+
+func GetConfig(key string, pathToRepo string) string {
+	// `git config` exits with 1 when the key is not set, which is not an error for us
+	output, _ := executeGit([]string{"config", "--get", key}, pathToRepo)
+	return strings.TrimSpace(output)
+}
+
+// Runs a long git command and reports the progress that git prints to stderr. Cancelling the
+// context kills the git process.
+func executeGitWithProgress(ctx context.Context, command []string, cwd string, onProgress func(GitProgress)) (string, error) {
+	var result bytes.Buffer
+	var er bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", command...)
+	cmd.Stdout = &result
+
+	// There is nobody to type a password into a terminal prompt
+	cmd.Env = gitEnvironment([]string{"GIT_TERMINAL_PROMPT=0"})
+
+	if cwd != "" {
+		cmd.Dir = cwd
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return "", newGitError(command, err, "", "")
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return "", newGitError(command, err, "", "")
+	}
+
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		progress, ok := ParseProgress(line)
+		if ok {
+			if onProgress != nil {
+				onProgress(progress)
+			}
+		} else if strings.TrimSpace(line) != "" {
+			er.WriteString(line)
+			er.WriteByte('\n')
+		}
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		return result.String(), newGitError(command, err, result.String(), er.String())
+	}
+
+	return result.String(), nil
+}
+
+// Git redraws progress lines in place with \r, so treat it as a line break just like \n
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for index, b := range data {
+		if b == '\r' || b == '\n' {
+			return index + 1, data[:index], nil
+		}
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
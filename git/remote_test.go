@@ -0,0 +1,247 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Creates a bare repository to act as a remote
+func newTestRemote(t *testing.T) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "remote.git")
+	runTestGit(t, "", "init", "-q", "--bare", "-b", "main", dir)
+
+	return dir
+}
+
+// A repository with one commit and the given remotes, none of which has been pushed to
+func newTestRepoWithRemotes(t *testing.T, remotes ...string) (dir string, remoteDirs []string) {
+	t.Helper()
+
+	dir = newTestRepo(t)
+	commitTestFile(t, dir, "file.txt", "one\n", "First")
+
+	for _, remote := range remotes {
+		remoteDir := newTestRemote(t)
+		runTestGit(t, dir, "remote", "add", remote, remoteDir)
+		remoteDirs = append(remoteDirs, remoteDir)
+	}
+
+	return
+}
+
+func cloneTestRepo(t *testing.T, remoteDir string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "clone")
+	runTestGit(t, "", "clone", "-q", remoteDir, dir)
+	runTestGit(t, dir, "config", "user.name", "Other")
+	runTestGit(t, dir, "config", "user.email", "other@example.com")
+
+	return dir
+}
+
+func pushTestRepo(t *testing.T, dir string, options GitPushOptions) error {
+	t.Helper()
+
+	return Push(context.Background(), options, dir, nil)
+}
+
+func remoteTestHead(t *testing.T, remoteDir string) string {
+	t.Helper()
+
+	return strings.TrimSpace(runTestGit(t, remoteDir, "rev-parse", "main"))
+}
+
+func TestPushSetsUpstreamOnTheOnlyRemote(t *testing.T) {
+	dir, remoteDirs := newTestRepoWithRemotes(t, "upstream")
+
+	if err := pushTestRepo(t, dir, GitPushOptions{SetUpstream: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	upstream, err := GetUpstream(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upstream != "upstream/main" {
+		t.Errorf("upstream is %q", upstream)
+	}
+
+	if head := strings.TrimSpace(runTestGit(t, dir, "rev-parse", "HEAD")); remoteTestHead(t, remoteDirs[0]) != head {
+		t.Errorf("remote main is not %s", head)
+	}
+}
+
+func TestPushSetsUpstreamOnThePushRemote(t *testing.T) {
+	dir, remoteDirs := newTestRepoWithRemotes(t, "origin", "fork")
+	runTestGit(t, dir, "config", "branch.main.pushRemote", "fork")
+
+	if err := pushTestRepo(t, dir, GitPushOptions{SetUpstream: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if upstream, _ := GetUpstream(dir); upstream != "fork/main" {
+		t.Errorf("upstream is %q", upstream)
+	}
+	if output := runTestGit(t, remoteDirs[0], "branch"); output != "" {
+		t.Errorf("origin has branches %q", output)
+	}
+}
+
+func TestPushWithoutUpstreamNeedsAnUnambiguousRemote(t *testing.T) {
+	dir, _ := newTestRepoWithRemotes(t, "origin", "fork")
+
+	if err := pushTestRepo(t, dir, GitPushOptions{SetUpstream: true}); err == nil {
+		t.Fatal("push picked one of several remotes")
+	}
+}
+
+func TestPushForceWithLeaseIsRejectedWhenTheRemoteMoved(t *testing.T) {
+	dir, remoteDirs := newTestRepoWithRemotes(t, "origin")
+	if err := pushTestRepo(t, dir, GitPushOptions{SetUpstream: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	other := cloneTestRepo(t, remoteDirs[0])
+	commitTestFile(t, other, "other.txt", "other\n", "Other")
+	runTestGit(t, other, "push", "-q")
+	remoteHead := remoteTestHead(t, remoteDirs[0])
+
+	// The local branch was rewritten without knowing about the other commit
+	runTestGit(t, dir, "commit", "-q", "--amend", "-m", "Rewritten")
+
+	err := pushTestRepo(t, dir, GitPushOptions{ForceWithLease: true})
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) {
+		t.Fatalf("push was not rejected: %v", err)
+	}
+	if remoteTestHead(t, remoteDirs[0]) != remoteHead {
+		t.Error("the remote branch was overwritten")
+	}
+
+	// Once the other commit has been seen, the lease holds
+	if err := Fetch(context.Background(), "", dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := pushTestRepo(t, dir, GitPushOptions{ForceWithLease: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAheadBehind(t *testing.T) {
+	dir, remoteDirs := newTestRepoWithRemotes(t, "origin")
+	if err := pushTestRepo(t, dir, GitPushOptions{SetUpstream: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	other := cloneTestRepo(t, remoteDirs[0])
+	commitTestFile(t, other, "other.txt", "other\n", "Other")
+	runTestGit(t, other, "push", "-q")
+
+	commitTestFile(t, dir, "file.txt", "two\n", "Second")
+	commitTestFile(t, dir, "file.txt", "three\n", "Third")
+
+	if err := Fetch(context.Background(), "origin", dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ahead, behind, err := AheadBehind(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ahead != 2 || behind != 1 {
+		t.Errorf("ahead %d, behind %d", ahead, behind)
+	}
+}
+
+func TestPullFastForwards(t *testing.T) {
+	dir, remoteDirs := newTestRepoWithRemotes(t, "origin")
+	if err := pushTestRepo(t, dir, GitPushOptions{SetUpstream: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	other := cloneTestRepo(t, remoteDirs[0])
+	commitTestFile(t, other, "other.txt", "other\n", "Other")
+	runTestGit(t, other, "push", "-q")
+
+	if err := Pull(context.Background(), dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if head := strings.TrimSpace(runTestGit(t, dir, "rev-parse", "HEAD")); head != remoteTestHead(t, remoteDirs[0]) {
+		t.Error("pull did not bring in the other commit")
+	}
+}
+
+func TestPullKeepsMergesWhenConfiguredTo(t *testing.T) {
+	dir, remoteDirs := newTestRepoWithRemotes(t, "origin")
+	if err := pushTestRepo(t, dir, GitPushOptions{SetUpstream: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	other := cloneTestRepo(t, remoteDirs[0])
+	commitTestFile(t, other, "other.txt", "other\n", "Other")
+	runTestGit(t, other, "push", "-q")
+
+	// A local merge commit that a plain --rebase would flatten
+	runTestGit(t, dir, "checkout", "-q", "-b", "topic")
+	commitTestFile(t, dir, "topic.txt", "topic\n", "Topic")
+	runTestGit(t, dir, "checkout", "-q", "main")
+	runTestGit(t, dir, "merge", "-q", "--no-ff", "-m", "Merge topic", "topic")
+
+	runTestGit(t, dir, "config", "branch.main.rebase", "merges")
+	if err := Pull(context.Background(), dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if parents := strings.Fields(runTestGit(t, dir, "log", "-1", "--format=%P")); len(parents) != 2 {
+		t.Errorf("HEAD has parents %v, the merge was flattened", parents)
+	}
+	if output := runTestGit(t, dir, "merge-base", "--is-ancestor", remoteTestHead(t, remoteDirs[0]), "HEAD"); output != "" {
+		t.Errorf("unexpected output %q", output)
+	}
+}
+
+func TestPushAndFetchReportProgress(t *testing.T) {
+	dir, remoteDirs := newTestRepoWithRemotes(t, "origin")
+
+	var pushProgress []GitProgress
+	err := Push(context.Background(), GitPushOptions{SetUpstream: true}, dir, func(progress GitProgress) {
+		pushProgress = append(pushProgress, progress)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectTestProgress(t, "push", pushProgress)
+
+	other := cloneTestRepo(t, remoteDirs[0])
+	commitTestFile(t, other, "other.txt", "other\n", "Other")
+	runTestGit(t, other, "push", "-q")
+
+	var fetchProgress []GitProgress
+	err = Fetch(context.Background(), "origin", dir, func(progress GitProgress) {
+		fetchProgress = append(fetchProgress, progress)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectTestProgress(t, "fetch", fetchProgress)
+}
+
+// Some phase has to be reported as finished
+func expectTestProgress(t *testing.T, what string, progress []GitProgress) {
+	t.Helper()
+
+	for _, step := range progress {
+		if step.Phase != "" && step.Percent == 100 && step.Current == step.Total {
+			return
+		}
+	}
+
+	t.Errorf("%s reported %+v", what, progress)
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Both sides of a diff as whole files, so that a hunk can be looked at together with everything
+// above it. A side that doesn't exist, e.g. the old side of a new file, is empty.
+type GitDiffSources struct {
+	Old string
+	New string
+}
+
+func GetEntrySources(entry GitStatusEntry, pathToRepo string) (result GitDiffSources) {
+	oldFilename := entry.Filename
+	if entry.OrigFilename != "" {
+		oldFilename = entry.OrigFilename
+	}
+
+	if entry.Staged {
+		result.Old = readRevisionFile("HEAD", oldFilename, pathToRepo)
+		result.New = readRevisionFile("", entry.Filename, pathToRepo)
+	} else if entry.Type == GIT_ENTRY_CONFLICTED {
+		result.Old = readRevisionFile("HEAD", oldFilename, pathToRepo)
+		result.New = readWorktreeFile(entry.Filename, pathToRepo)
+	} else if entry.Type != GIT_ENTRY_NEW_UNSTAGED {
+		result.Old = readRevisionFile("", oldFilename, pathToRepo)
+		result.New = readWorktreeFile(entry.Filename, pathToRepo)
+	} else {
+		result.New = readWorktreeFile(entry.Filename, pathToRepo)
+	}
+
+	return
+}
+
+func GetCommitEntrySources(commit GitCommit, entry GitStatusEntry, pathToRepo string) (result GitDiffSources) {
+	oldFilename := entry.Filename
+	if entry.OrigFilename != "" {
+		oldFilename = entry.OrigFilename
+	}
+
+	if len(commit.Parents) > 0 {
+		result.Old = readRevisionFile(commit.Parents[0], oldFilename, pathToRepo)
+	}
+	result.New = readRevisionFile(commit.Hash, entry.Filename, pathToRepo)
+
+	return
+}
+
+func GetStashEntrySources(stash GitStashEntry, entry GitStatusEntry, pathToRepo string) (result GitDiffSources) {
+	if entry.Type == GIT_ENTRY_NEW_UNSTAGED {
+		result.New = readRevisionFile(stash.Hash+"^3", entry.Filename, pathToRepo)
+		return
+	}
+
+	oldFilename := entry.Filename
+	if entry.OrigFilename != "" {
+		oldFilename = entry.OrigFilename
+	}
+
+	result.Old = readRevisionFile(stash.Hash+"^1", oldFilename, pathToRepo)
+	result.New = readRevisionFile(stash.Hash, entry.Filename, pathToRepo)
+
+	return
+}
+
+// An empty revision reads the file from the index. Missing files read as empty.
+func readRevisionFile(revision string, filename string, pathToRepo string) string {
+	output, err := executeGit([]string{"show", revision + ":" + filename}, pathToRepo)
+	if err != nil {
+		return ""
+	}
+
+	return output
+}
+
+func readWorktreeFile(filename string, pathToRepo string) string {
+	contents, err := os.ReadFile(filepath.Join(pathToRepo, filename))
+	if err != nil {
+		return ""
+	}
+
+	return string(contents)
+}
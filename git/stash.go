@@ -0,0 +1,126 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Stashes only the given files, untracked ones included
+func StashSelectedFiles(filenames []string, message string, pathToRepo string) error {
+	command := []string{"stash", "push", "--include-untracked"}
+	if message != "" {
+		command = append(command, "-m", message)
+	}
+
+	_, err := executeGit(append(append(command, "--"), filenames...), pathToRepo)
+	return err
+}
+
+// Applies a stash and keeps it in the list, unlike PopStash
+func ApplyStash(index string, pathToRepo string) (result []GitStatusEntry, err error) {
+	_, err = executeGit([]string{"stash", "apply", index}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return Status(pathToRepo)
+}
+
+// Git has no way to change a stash message in place, so the stash is stored again with the new
+// message, which moves it to the top of the list, and the old entry is dropped
+func RenameStash(entry GitStashEntry, message string, pathToRepo string) error {
+	number, err := stashNumber(entry.Index)
+	if err != nil {
+		return err
+	}
+
+	// `git stash push` would have added the branch in front of the message itself
+	if entry.BranchName != "" {
+		message = fmt.Sprintf("On %s: %s", entry.BranchName, message)
+	}
+
+	_, err = executeGit([]string{"stash", "store", "-m", message, entry.Hash}, pathToRepo)
+	if err != nil {
+		return err
+	}
+
+	return DeleteStash(fmt.Sprintf("stash@{%d}", number+1), pathToRepo)
+}
+
+// Creates a branch at the commit the stash was made on, checks it out and pops the stash there
+func BranchFromStash(index string, branchName string, pathToRepo string) error {
+	_, err := executeGit([]string{"stash", "branch", branchName, index}, pathToRepo)
+	return err
+}
+
+// Lists the files in a stash. Untracked files are stored in a separate commit and come back as
+// GIT_ENTRY_NEW_UNSTAGED entries.
+func StashFiles(entry GitStashEntry, pathToRepo string) (result []GitStatusEntry, err error) {
+	output, err := executeGit([]string{"diff", "-M", "--name-status", "-z", entry.Hash + "^1", entry.Hash}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	result = ParseNameStatus(output)
+
+	if !hasUntrackedCommit(entry, pathToRepo) {
+		return
+	}
+
+	output, err = executeGit([]string{"ls-tree", "-r", "-z", "--name-only", entry.Hash + "^3"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	for _, filename := range strings.Split(output, "\x00") {
+		if filename == "" {
+			continue
+		}
+
+		result = append(result, GitStatusEntry{
+			Filename:      filename,
+			Type:          GIT_ENTRY_NEW_UNSTAGED,
+			IndexState:    GIT_STATE_UNMODIFIED,
+			WorktreeState: GIT_STATE_UNTRACKED,
+		})
+	}
+
+	return
+}
+
+func DiffStashEntry(stash GitStashEntry, entry GitStatusEntry, options GitDiffOptions, pathToRepo string) (result GitDiff, err error) {
+	paths := []string{entry.Filename}
+	if entry.OrigFilename != "" {
+		paths = append(paths, entry.OrigFilename)
+	}
+
+	var command []string
+	if entry.Type == GIT_ENTRY_NEW_UNSTAGED {
+		// The untracked files commit has no parent, so showing it diffs against nothing
+		command = append([]string{"show", "--format="}, options.args()...)
+		command = append(command, stash.Hash+"^3")
+	} else {
+		command = append([]string{"diff", "-M"}, options.args()...)
+		command = append(command, stash.Hash+"^1", stash.Hash)
+	}
+
+	command = append(append(command, "--"), paths...)
+	output, err := executeGit(command, pathToRepo)
+
+	if err != nil {
+		return
+	}
+
+	return parseDiffWithOptions(output, options), nil
+}
+
+func hasUntrackedCommit(entry GitStashEntry, pathToRepo string) bool {
+	_, err := executeGit([]string{"rev-parse", "--verify", "--quiet", entry.Hash + "^3"}, pathToRepo)
+	return err == nil
+}
+
+func stashNumber(index string) (int, error) {
+	number := strings.TrimSuffix(strings.TrimPrefix(index, "stash@{"), "}")
+	return strconv.Atoi(number)
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type GitTag struct {
+	Name      string
+	Target    string // Commit the tag points to, annotated tags are peeled
+	Annotated bool
+	Message   string // Subject of the tag message, or of the commit for lightweight tags
+	Date      time.Time
+}
+
+const tagFormat = "--format=%(refname:short)%00%(objecttype)%00%(objectname)%00%(*objectname)%00%(contents:subject)%00%(creatordate:unix)%00"
+
+// Lists all tags, newest first
+func ListTags(pathToRepo string) (result []GitTag, err error) {
+	output, err := executeGit([]string{"for-each-ref", "--sort=-creatordate", tagFormat, "refs/tags"}, pathToRepo)
+	if err != nil {
+		return
+	}
+
+	return ParseTags(output), nil
+}
+
+// Creates an annotated tag on revision, or on HEAD if revision is empty
+func CreateTag(name string, message string, revision string, pathToRepo string) error {
+	command := []string{"tag", "-a", name, "-m", message}
+	if revision != "" {
+		command = append(command, revision)
+	}
+
+	_, err := executeGit(command, pathToRepo)
+	return err
+}
+
+func DeleteTag(name string, pathToRepo string) error {
+	_, err := executeGit([]string{"tag", "-d", name}, pathToRepo)
+	return err
+}
+
+func PushTag(ctx context.Context, remote string, name string, pathToRepo string, onProgress func(GitProgress)) error {
+	_, err := executeGitWithProgress(ctx, []string{"push", "--progress", remote, "refs/tags/" + name}, pathToRepo, onProgress)
+	return err
+}
+
+func PushAllTags(ctx context.Context, remote string, pathToRepo string, onProgress func(GitProgress)) error {
+	_, err := executeGitWithProgress(ctx, []string{"push", "--progress", "--tags", remote}, pathToRepo, onProgress)
+	return err
+}
+
+func DeleteRemoteTag(ctx context.Context, remote string, name string, pathToRepo string, onProgress func(GitProgress)) error {
+	_, err := executeGitWithProgress(ctx, []string{"push", "--progress", remote, "--delete", "refs/tags/" + name}, pathToRepo, onProgress)
+	return err
+}
+
+// Checks out a tag or any other revision without a branch
+func CheckoutDetached(revision string, pathToRepo string) error {
+	_, err := executeGit([]string{"checkout", "--detach", revision}, pathToRepo)
+	return err
+}
+
+// Describes a detached HEAD by the tag it is on, or by its short hash if there is none
+func GetDetachedHeadName(pathToRepo string) (string, error) {
+	output, err := executeGit([]string{"describe", "--tags", "--exact-match", "HEAD"}, pathToRepo)
+	if err == nil {
+		return strings.TrimSpace(output), nil
+	}
+
+	output, err = executeGit([]string{"rev-parse", "--short", "HEAD"}, pathToRepo)
+	return strings.TrimSpace(output), err
+}
@@ -4,6 +4,13 @@ type Input struct {
 	TypedCharacter byte
 	Backspace      bool
 	Escape         bool
+	Delete         bool
+	Up             bool
+	Down           bool
+	Left           bool
+	Right          bool
+	Home           bool
+	End            bool
 	Ctrl           bool
 	Alt            bool
 	Shift          bool
@@ -13,4 +20,11 @@ func (input *Input) Clear() {
 	input.TypedCharacter = 0
 	input.Backspace = false
 	input.Escape = false
+	input.Delete = false
+	input.Up = false
+	input.Down = false
+	input.Left = false
+	input.Right = false
+	input.Home = false
+	input.End = false
 }
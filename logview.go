@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DonutLaser/git-client/font"
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// How many commits are loaded at once, more are loaded when the cursor gets close to the end
+const LOG_PAGE_SIZE = 500
+
+const GRAPH_LANE_WIDTH = 14
+
+var graphLaneColors = []sdl.Color{
+	{R: 38, G: 139, B: 210, A: 255},
+	{R: 82, G: 153, B: 19, A: 255},
+	{R: 207, G: 173, B: 16, A: 255},
+	{R: 211, G: 54, B: 130, A: 255},
+	{R: 42, G: 161, B: 152, A: 255},
+	{R: 203, G: 75, B: 22, A: 255},
+	{R: 108, G: 113, B: 196, A: 255},
+	{R: 169, G: 26, B: 23, A: 255},
+}
+
+type LogView struct {
+	Rect *sdl.Rect
+
+	Commits      []git.GitCommit
+	Graph        git.GitGraph
+	ActiveCommit int
+	FirstVisible int
+	AllLoaded    bool
+}
+
+func NewLogView(windowWidth int32, windowHeight int32) (result LogView) {
+	result.Rect = &sdl.Rect{X: 0, Y: 24 + 2, W: windowWidth, H: windowHeight - 24 - 2}
+
+	return
+}
+
+func (log *LogView) Resize(windowWidth int32, windowHeight int32) {
+	log.Rect.W = windowWidth
+	log.Rect.H = windowHeight - 24 - 2
+
+	log.scrollToActiveCommit()
+}
+
+func (log *LogView) Clear() {
+	log.Commits = nil
+	log.Graph.Clear()
+	log.ActiveCommit = 0
+	log.FirstVisible = 0
+	log.AllLoaded = false
+}
+
+func (log *LogView) AppendCommits(commits []git.GitCommit) {
+	log.Commits = append(log.Commits, commits...)
+	log.Graph.AddCommits(commits)
+}
+
+func (log *LogView) NeedsMoreCommits() bool {
+	return !log.AllLoaded && log.ActiveCommit+log.visibleRows() >= len(log.Commits)
+}
+
+func (log *LogView) GoToNextCommit(amount int) {
+	log.ActiveCommit += amount
+	if log.ActiveCommit >= len(log.Commits) {
+		log.ActiveCommit = len(log.Commits) - 1
+	}
+	if log.ActiveCommit < 0 {
+		log.ActiveCommit = 0
+	}
+
+	log.scrollToActiveCommit()
+}
+
+func (log *LogView) GoToPrevCommit(amount int) {
+	log.ActiveCommit -= amount
+	if log.ActiveCommit < 0 {
+		log.ActiveCommit = 0
+	}
+
+	log.scrollToActiveCommit()
+}
+
+// Moves the cursor to the commit if it has been loaded already
+func (log *LogView) SelectCommit(hash string) bool {
+	for index, commit := range log.Commits {
+		if commit.Hash == hash {
+			log.ActiveCommit = index
+			log.scrollToActiveCommit()
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func (log *LogView) HasCommits() bool {
+	return len(log.Commits) > 0
+}
+
+func (log *LogView) GetActiveCommit() git.GitCommit {
+	return log.Commits[log.ActiveCommit]
+}
+
+func (log *LogView) visibleRows() int {
+	return int(log.Rect.H / 24)
+}
+
+func (log *LogView) scrollToActiveCommit() {
+	if log.ActiveCommit < log.FirstVisible {
+		log.FirstVisible = log.ActiveCommit
+	} else if log.ActiveCommit >= log.FirstVisible+log.visibleRows() {
+		log.FirstVisible = log.ActiveCommit - log.visibleRows() + 1
+	}
+}
+
+func (log *LogView) Render(rend *sdl.Renderer, app *App) {
+	renderer.DrawRect(rend, log.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+
+	mainFont := app.Fonts["12"]
+
+	if len(log.Commits) == 0 {
+		text := "No commits yet"
+		textWidth := mainFont.GetStringWidth(text)
+		textRect := sdl.Rect{
+			X: log.Rect.X + (log.Rect.W-textWidth)/2,
+			Y: log.Rect.Y + (log.Rect.H-mainFont.Size)/2,
+			W: textWidth,
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, text, &textRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+
+		return
+	}
+
+	renderer.ClipRect(rend, log.Rect)
+
+	var rowHeight int32 = 24
+
+	// Keep the text aligned for all visible rows instead of moving it with the width of each row's graph
+	lastVisible := log.FirstVisible + log.visibleRows() + 1
+	graphLanes := 0
+	for index := log.FirstVisible; index < len(log.Graph.Rows) && index < lastVisible; index += 1 {
+		graphLanes = maxInt(graphLanes, graphRowLanes(log.Graph.Rows[index]))
+	}
+
+	top := log.Rect.Y
+	for index := log.FirstVisible; index < len(log.Commits) && top < log.Rect.Y+log.Rect.H; index += 1 {
+		commit := log.Commits[index]
+
+		rowRect := sdl.Rect{X: log.Rect.X, Y: top, W: log.Rect.W, H: rowHeight}
+		if index == log.ActiveCommit {
+			renderer.DrawRect(rend, &rowRect, sdl.Color{R: 77, G: 77, B: 77, A: 255})
+			renderer.DrawRectOutline(rend, &rowRect, sdl.Color{R: 92, G: 91, B: 92, A: 255}, 1)
+		}
+
+		textTop := top + (rowHeight-mainFont.Size)/2
+		left := log.Rect.X + 10
+
+		if index < len(log.Graph.Rows) {
+			log.renderGraphRow(rend, log.Graph.Rows[index], left, top, rowHeight)
+		}
+		left += int32(graphLanes)*GRAPH_LANE_WIDTH + 6
+
+		left = log.renderText(rend, &mainFont, commit.ShortHash, left, textTop, sdl.Color{R: 207, G: 173, B: 16, A: 255}) + 10
+
+		for _, ref := range commit.Refs {
+			left = log.renderRef(rend, &mainFont, ref, left, top, rowHeight) + 6
+		}
+		left += 4
+
+		// Author and date are right aligned, the subject takes whatever is left in between
+		date := relativeTime(commit.AuthorDate)
+		dateLeft := log.Rect.X + log.Rect.W - 10 - mainFont.GetStringWidth(date)
+		authorLeft := dateLeft - 20 - mainFont.GetStringWidth(commit.AuthorName)
+
+		subject := commit.Subject
+		maxSubjectChars := int((authorLeft - 20 - left) / mainFont.CharacterWidth)
+		if maxSubjectChars < 0 {
+			maxSubjectChars = 0
+		}
+		if len(subject) > maxSubjectChars {
+			subject = truncateText(subject, maxSubjectChars)
+		}
+
+		log.renderText(rend, &mainFont, subject, left, textTop, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+		log.renderText(rend, &mainFont, commit.AuthorName, authorLeft, textTop, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+		log.renderText(rend, &mainFont, date, dateLeft, textTop, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		top += rowHeight
+	}
+
+	renderer.ClipRect(rend, nil)
+}
+
+func (log *LogView) renderGraphRow(rend *sdl.Renderer, row git.GitGraphRow, left int32, top int32, rowHeight int32) {
+	laneCenter := func(column int) int32 {
+		return left + int32(column)*GRAPH_LANE_WIDTH + GRAPH_LANE_WIDTH/2
+	}
+
+	middle := top + rowHeight/2
+
+	for _, line := range row.Top {
+		color := graphLaneColors[line.Color%len(graphLaneColors)]
+		renderer.DrawLine(rend, &sdl.Point{X: laneCenter(line.From), Y: top}, &sdl.Point{X: laneCenter(line.To), Y: middle}, color)
+		renderer.DrawLine(rend, &sdl.Point{X: laneCenter(line.From) + 1, Y: top}, &sdl.Point{X: laneCenter(line.To) + 1, Y: middle}, color)
+	}
+
+	for _, line := range row.Bottom {
+		color := graphLaneColors[line.Color%len(graphLaneColors)]
+		renderer.DrawLine(rend, &sdl.Point{X: laneCenter(line.From), Y: middle}, &sdl.Point{X: laneCenter(line.To), Y: top + rowHeight}, color)
+		renderer.DrawLine(rend, &sdl.Point{X: laneCenter(line.From) + 1, Y: middle}, &sdl.Point{X: laneCenter(line.To) + 1, Y: top + rowHeight}, color)
+	}
+
+	nodeRect := sdl.Rect{X: laneCenter(row.Column) - 3, Y: middle - 3, W: 8, H: 8}
+	renderer.DrawRect(rend, &nodeRect, graphLaneColors[row.Color%len(graphLaneColors)])
+}
+
+func (log *LogView) renderText(rend *sdl.Renderer, ffont *font.Font, text string, left int32, top int32, color sdl.Color) int32 {
+	width := ffont.GetStringWidth(text)
+	rect := sdl.Rect{X: left, Y: top, W: width, H: ffont.Size}
+	renderer.DrawText(rend, ffont, text, &rect, color)
+
+	return left + width
+}
+
+func (log *LogView) renderRef(rend *sdl.Renderer, ffont *font.Font, ref string, left int32, top int32, rowHeight int32) int32 {
+	color := sdl.Color{R: 38, G: 139, B: 210, A: 255}
+	if strings.HasPrefix(ref, "tag: ") {
+		color = sdl.Color{R: 211, G: 54, B: 130, A: 255}
+	} else if strings.HasPrefix(ref, "HEAD") {
+		color = sdl.Color{R: 82, G: 153, B: 19, A: 255}
+	}
+
+	width := ffont.GetStringWidth(ref)
+	bgRect := sdl.Rect{X: left, Y: top + 4, W: width + 8, H: rowHeight - 8}
+	renderer.DrawRectOutline(rend, &bgRect, color, 1)
+
+	log.renderText(rend, ffont, ref, left+4, top+(rowHeight-ffont.Size)/2, color)
+
+	return bgRect.X + bgRect.W
+}
+
+func graphRowLanes(row git.GitGraphRow) (result int) {
+	result = row.Column + 1
+
+	for _, line := range row.Top {
+		result = maxInt(result, maxInt(line.From, line.To)+1)
+	}
+	for _, line := range row.Bottom {
+		result = maxInt(result, maxInt(line.From, line.To)+1)
+	}
+
+	return
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func relativeTime(t time.Time) string {
+	elapsed := time.Since(t)
+
+	pluralize := func(amount int, unit string) string {
+		if amount == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+
+		return fmt.Sprintf("%d %ss ago", amount, unit)
+	}
+
+	if elapsed < time.Minute {
+		return "just now"
+	} else if elapsed < time.Hour {
+		return pluralize(int(elapsed.Minutes()), "minute")
+	} else if elapsed < 24*time.Hour {
+		return pluralize(int(elapsed.Hours()), "hour")
+	} else if elapsed < 30*24*time.Hour {
+		return pluralize(int(elapsed.Hours()/24), "day")
+	} else if elapsed < 365*24*time.Hour {
+		return pluralize(int(elapsed.Hours()/24/30), "month")
+	}
+
+	return pluralize(int(elapsed.Hours()/24/365), "year")
+}
+
+// Counts characters rather than bytes, so that a multi-byte character is never cut in half
+func truncateText(text string, maxChars int) string {
+	if maxChars <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+
+	if maxChars <= 3 {
+		return string(runes[:maxChars])
+	}
+
+	return string(runes[:maxChars-3]) + "..."
+}
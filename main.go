@@ -190,6 +190,34 @@ func main() {
 					if t.State != sdl.RELEASED {
 						input.Escape = true
 					}
+				case sdl.K_DELETE:
+					if t.State != sdl.RELEASED {
+						input.Delete = true
+					}
+				case sdl.K_UP:
+					if t.State != sdl.RELEASED {
+						input.Up = true
+					}
+				case sdl.K_DOWN:
+					if t.State != sdl.RELEASED {
+						input.Down = true
+					}
+				case sdl.K_LEFT:
+					if t.State != sdl.RELEASED {
+						input.Left = true
+					}
+				case sdl.K_RIGHT:
+					if t.State != sdl.RELEASED {
+						input.Right = true
+					}
+				case sdl.K_HOME:
+					if t.State != sdl.RELEASED {
+						input.Home = true
+					}
+				case sdl.K_END:
+					if t.State != sdl.RELEASED {
+						input.End = true
+					}
 				default:
 					if t.State != sdl.RELEASED {
 						input.TypedCharacter = keyToCharacter(keycode, t.Keysym.Mod)
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type RebasePlanner struct {
+	Rect *sdl.Rect
+
+	Base         string
+	Steps        []git.GitRebaseStep
+	ActiveStep   int
+	FirstVisible int
+}
+
+func NewRebasePlanner(windowWidth int32, windowHeight int32) (result RebasePlanner) {
+	result.Rect = &sdl.Rect{X: 0, Y: 24 + 2, W: windowWidth, H: windowHeight - 24 - 2}
+
+	return
+}
+
+func (planner *RebasePlanner) Resize(windowWidth int32, windowHeight int32) {
+	planner.Rect.W = windowWidth
+	planner.Rect.H = windowHeight - 24 - 2
+
+	planner.scrollToActiveStep()
+}
+
+// Every commit starts out as a pick, which replays them unchanged
+func (planner *RebasePlanner) ShowCommits(base string, commits []git.GitCommit) {
+	planner.Base = base
+	planner.Steps = make([]git.GitRebaseStep, len(commits))
+	for index, commit := range commits {
+		planner.Steps[index] = git.GitRebaseStep{Action: git.GIT_REBASE_PICK, Commit: commit}
+	}
+
+	planner.ActiveStep = 0
+	planner.FirstVisible = 0
+}
+
+func (planner *RebasePlanner) SetAction(action git.GitRebaseAction) {
+	if planner.ActiveStep < len(planner.Steps) {
+		planner.Steps[planner.ActiveStep].Action = action
+	}
+}
+
+func (planner *RebasePlanner) GoToNextStep() {
+	if planner.ActiveStep < len(planner.Steps)-1 {
+		planner.ActiveStep += 1
+	}
+
+	planner.scrollToActiveStep()
+}
+
+func (planner *RebasePlanner) GoToPrevStep() {
+	if planner.ActiveStep > 0 {
+		planner.ActiveStep -= 1
+	}
+
+	planner.scrollToActiveStep()
+}
+
+func (planner *RebasePlanner) MoveStepDown() {
+	if planner.ActiveStep >= len(planner.Steps)-1 {
+		return
+	}
+
+	planner.Steps[planner.ActiveStep], planner.Steps[planner.ActiveStep+1] = planner.Steps[planner.ActiveStep+1], planner.Steps[planner.ActiveStep]
+	planner.GoToNextStep()
+}
+
+func (planner *RebasePlanner) MoveStepUp() {
+	if planner.ActiveStep <= 0 || planner.ActiveStep >= len(planner.Steps) {
+		return
+	}
+
+	planner.Steps[planner.ActiveStep], planner.Steps[planner.ActiveStep-1] = planner.Steps[planner.ActiveStep-1], planner.Steps[planner.ActiveStep]
+	planner.GoToPrevStep()
+}
+
+func (planner *RebasePlanner) visibleRows() int {
+	// The first row is taken by the title
+	return int(planner.Rect.H/24) - 1
+}
+
+func (planner *RebasePlanner) scrollToActiveStep() {
+	if planner.ActiveStep < planner.FirstVisible {
+		planner.FirstVisible = planner.ActiveStep
+	} else if planner.ActiveStep >= planner.FirstVisible+planner.visibleRows() {
+		planner.FirstVisible = planner.ActiveStep - planner.visibleRows() + 1
+	}
+}
+
+func (planner *RebasePlanner) Render(rend *sdl.Renderer, app *App) {
+	renderer.DrawRect(rend, planner.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+	renderer.ClipRect(rend, planner.Rect)
+
+	mainFont := app.Fonts["12"]
+
+	var rowHeight int32 = 24
+
+	title := fmt.Sprintf("Rebase %d commits onto %s, oldest first", len(planner.Steps), planner.Base)
+	titleRect := sdl.Rect{
+		X: planner.Rect.X + 10,
+		Y: planner.Rect.Y + (rowHeight-mainFont.Size)/2,
+		W: mainFont.GetStringWidth(title),
+		H: mainFont.Size,
+	}
+	renderer.DrawText(rend, &mainFont, title, &titleRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+	top := planner.Rect.Y + rowHeight
+	for index := planner.FirstVisible; index < len(planner.Steps) && top < planner.Rect.Y+planner.Rect.H; index += 1 {
+		step := planner.Steps[index]
+
+		rowRect := sdl.Rect{X: planner.Rect.X, Y: top, W: planner.Rect.W, H: rowHeight}
+		if index == planner.ActiveStep {
+			renderer.DrawRect(rend, &rowRect, sdl.Color{R: 77, G: 77, B: 77, A: 255})
+			renderer.DrawRectOutline(rend, &rowRect, sdl.Color{R: 92, G: 91, B: 92, A: 255}, 1)
+		}
+
+		textTop := top + (rowHeight-mainFont.Size)/2
+		left := planner.Rect.X + 10
+
+		actionName := git.RebaseActionName(step.Action)
+		actionRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(actionName), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, actionName, &actionRect, planner.actionToColor(step.Action))
+		left += mainFont.CharacterWidth*7 + 10
+
+		hashRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(step.Commit.ShortHash), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, step.Commit.ShortHash, &hashRect, sdl.Color{R: 207, G: 173, B: 16, A: 255})
+		left += hashRect.W + 10
+
+		subjectColor := sdl.Color{R: 221, G: 221, B: 221, A: 255}
+		if step.Action == git.GIT_REBASE_DROP {
+			subjectColor = sdl.Color{R: 127, G: 127, B: 127, A: 255}
+		}
+
+		maxSubjectChars := int((planner.Rect.X + planner.Rect.W - 10 - left) / mainFont.CharacterWidth)
+		if maxSubjectChars < 0 {
+			maxSubjectChars = 0
+		}
+
+		subject := truncateText(step.Commit.Subject, maxSubjectChars)
+		subjectRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(subject), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, subject, &subjectRect, subjectColor)
+
+		top += rowHeight
+	}
+
+	renderer.ClipRect(rend, nil)
+}
+
+func (planner *RebasePlanner) actionToColor(action git.GitRebaseAction) sdl.Color {
+	switch action {
+	case git.GIT_REBASE_PICK:
+		return sdl.Color{R: 171, G: 171, B: 171, A: 255}
+	case git.GIT_REBASE_REWORD:
+		return sdl.Color{R: 38, G: 139, B: 210, A: 255}
+	case git.GIT_REBASE_EDIT:
+		return sdl.Color{R: 207, G: 173, B: 16, A: 255}
+	case git.GIT_REBASE_SQUASH:
+		fallthrough
+	case git.GIT_REBASE_FIXUP:
+		return sdl.Color{R: 82, G: 153, B: 19, A: 255}
+	case git.GIT_REBASE_DROP:
+		return sdl.Color{R: 169, G: 26, B: 23, A: 255}
+	default:
+		panic("Unreachable")
+	}
+}
@@ -56,3 +56,8 @@ func DrawImage(renderer *sdl.Renderer, img *image.Image, position *sdl.Point, co
 func ClipRect(renderer *sdl.Renderer, rect *sdl.Rect) {
 	renderer.SetClipRect(rect)
 }
+
+func DrawLine(renderer *sdl.Renderer, from *sdl.Point, to *sdl.Point, color sdl.Color) {
+	renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+	renderer.DrawLine(from.X, from.Y, to.X, to.Y)
+}
@@ -0,0 +1,49 @@
+package settings
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+
+	"github.com/DonutLaser/git-client/filesystem"
+)
+
+// Unfinished commit messages are kept in a file per repository and branch, so that they are
+// not lost when switching branches or closing the app
+
+func LoadDraft(repoPath string, branchName string) string {
+	draftPath := getDraftPath(repoPath, branchName)
+	if !filesystem.DoesPathExist(draftPath) {
+		return ""
+	}
+
+	contents, _ := filesystem.ReadFile(draftPath)
+	return contents
+}
+
+// An empty message removes the draft
+func SaveDraft(repoPath string, branchName string, message string) {
+	draftPath := getDraftPath(repoPath, branchName)
+
+	if message == "" {
+		filesystem.DeleteFile(draftPath)
+		return
+	}
+
+	draftsDir := getDraftsDir()
+	if !filesystem.DoesPathExist(draftsDir) {
+		filesystem.CreateDirectory(draftsDir)
+	}
+
+	filesystem.WriteFile(draftPath, message)
+}
+
+func getDraftsDir() string {
+	cacheDir, _ := os.UserCacheDir()
+	return fmt.Sprintf("%s/gitgud-drafts", cacheDir)
+}
+
+// Repository paths and branch names can contain characters that are not allowed in file names
+func getDraftPath(repoPath string, branchName string) string {
+	return fmt.Sprintf("%s/%x", getDraftsDir(), sha1.Sum([]byte(repoPath+"\x00"+branchName)))
+}
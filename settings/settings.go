@@ -3,6 +3,8 @@ package settings
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/DonutLaser/git-client/filesystem"
@@ -13,6 +15,75 @@ type Settings struct {
 	RepoList     []string
 	ActiveRepo   string
 	ActiveBranch string
+
+	// Branch lists show the most recently committed to branches first instead of sorting by name
+	BranchSortByDate bool
+
+	// With DIFF_LAYOUT_AUTO diffs are shown in a single pane instead of side by side when the window
+	// is narrower than UnifiedDiffBelowWidth, which is off when 0
+	DiffLayout            DiffLayout
+	UnifiedDiffBelowWidth int
+
+	// Unchanged lines shown around each hunk by default. Hunks without any can't be staged with
+	// `git apply`, so this is at least 1.
+	DiffContextLines int
+
+	// By repository path, "*" holds the rules for repositories without their own
+	CommitRules map[string]CommitRules
+
+	// By repository path, repositories without an entry use the zero value
+	DiffOptions map[string]DiffOptions
+}
+
+type DiffLayout int
+
+const (
+	DIFF_LAYOUT_AUTO DiffLayout = iota
+	DIFF_LAYOUT_UNIFIED
+	DIFF_LAYOUT_SPLIT
+)
+
+// How whitespace is treated when showing diffs
+type DiffOptions struct {
+	IgnoreSpaceChange bool
+	IgnoreAllSpace    bool
+	IgnoreBlankLines  bool
+	IgnoreCRAtEOL     bool
+
+	// Spaces, tabs and carriage returns in changed lines are drawn as glyphs
+	ShowWhitespace bool
+}
+
+// Checks that commit messages have to pass before they are committed. Zero values turn a check off.
+type CommitRules struct {
+	Types               []string
+	MaxSubjectLength    int
+	BlankLineBeforeBody bool
+	NoTrailingPeriod    bool
+}
+
+func (settings *Settings) GetCommitRules(repoPath string) CommitRules {
+	if rules, ok := settings.CommitRules[repoPath]; ok {
+		return rules
+	}
+
+	return settings.CommitRules["*"]
+}
+
+func (settings *Settings) GetDiffOptions(repoPath string) DiffOptions {
+	return settings.DiffOptions[repoPath]
+}
+
+func (settings *Settings) SetDiffOptions(repoPath string, options DiffOptions) {
+	if options == (DiffOptions{}) {
+		delete(settings.DiffOptions, repoPath)
+		return
+	}
+
+	if settings.DiffOptions == nil {
+		settings.DiffOptions = make(map[string]DiffOptions)
+	}
+	settings.DiffOptions[repoPath] = options
 }
 
 func (settings *Settings) AddRepo(repoPath string) {
@@ -48,6 +119,42 @@ func (settings *Settings) Save() {
 	sb.WriteString(fmt.Sprintf("active_repo=%s\n", settings.ActiveRepo))
 	sb.WriteString(fmt.Sprintf("active_branch=%s\n", settings.ActiveBranch))
 
+	branchSort := "name"
+	if settings.BranchSortByDate {
+		branchSort = "date"
+	}
+	sb.WriteString(fmt.Sprintf("branch_sort=%s\n", branchSort))
+
+	diffLayout := "auto"
+	if settings.DiffLayout == DIFF_LAYOUT_UNIFIED {
+		diffLayout = "unified"
+	} else if settings.DiffLayout == DIFF_LAYOUT_SPLIT {
+		diffLayout = "split"
+	}
+	sb.WriteString(fmt.Sprintf("diff_layout=%s\n", diffLayout))
+	sb.WriteString(fmt.Sprintf("unified_diff_below_width=%d\n", settings.UnifiedDiffBelowWidth))
+	sb.WriteString(fmt.Sprintf("diff_context=%d\n", settings.DiffContextLines))
+
+	repoPaths := make([]string, 0, len(settings.CommitRules))
+	for repoPath := range settings.CommitRules {
+		repoPaths = append(repoPaths, repoPath)
+	}
+	sort.Strings(repoPaths)
+
+	for _, repoPath := range repoPaths {
+		sb.WriteString(fmt.Sprintf("commit_rules=%s\n", formatCommitRules(repoPath, settings.CommitRules[repoPath])))
+	}
+
+	repoPaths = repoPaths[:0]
+	for repoPath := range settings.DiffOptions {
+		repoPaths = append(repoPaths, repoPath)
+	}
+	sort.Strings(repoPaths)
+
+	for _, repoPath := range repoPaths {
+		sb.WriteString(fmt.Sprintf("diff_options=%s\n", formatDiffOptions(repoPath, settings.DiffOptions[repoPath])))
+	}
+
 	filesystem.WriteFile(getSettingsPath(), sb.String())
 
 }
@@ -60,6 +167,8 @@ func OpenSettingsInExternalProgram() {
 func LoadSettings() (result Settings) {
 	settingsPath := getSettingsPath()
 
+	result.DiffContextLines = 3
+
 	if !filesystem.DoesPathExist(settingsPath) {
 		result.Save()
 		return
@@ -86,17 +195,140 @@ func LoadSettings() (result Settings) {
 			result.ActiveRepo = value
 		} else if key == "active_branch" {
 			result.ActiveBranch = value
+		} else if key == "branch_sort" {
+			result.BranchSortByDate = value == "date"
+		} else if key == "diff_layout" {
+			if value == "unified" {
+				result.DiffLayout = DIFF_LAYOUT_UNIFIED
+			} else if value == "split" {
+				result.DiffLayout = DIFF_LAYOUT_SPLIT
+			}
+		} else if key == "unified_diff_below_width" {
+			result.UnifiedDiffBelowWidth, _ = strconv.Atoi(value)
+		} else if key == "diff_context" {
+			if contextLines, err := strconv.Atoi(value); err == nil && contextLines > 0 {
+				result.DiffContextLines = contextLines
+			}
+		} else if key == "commit_rules" {
+			if result.CommitRules == nil {
+				result.CommitRules = make(map[string]CommitRules)
+			}
+
+			repoPath, rules := parseCommitRules(value)
+			result.CommitRules[repoPath] = rules
+		} else if key == "diff_options" {
+			repoPath, options := parseDiffOptions(value)
+			result.SetDiffOptions(repoPath, options)
 		}
 	}
 
 	return
 }
 
+// Only the first "=" separates the key, values may contain more of them
 func getKeyValuePair(text string) (string, string) {
-	split := strings.Split(text, "=")
+	split := strings.SplitN(text, "=", 2)
+	if len(split) < 2 {
+		return split[0], ""
+	}
+
 	return split[0], split[1]
 }
 
+// e.g. "/path/to/repo;types=feat,fix;max_subject=72;blank_line_before_body=true;no_trailing_period=true"
+func parseCommitRules(value string) (repoPath string, result CommitRules) {
+	fields := strings.Split(value, ";")
+	repoPath = strings.TrimSpace(fields[0])
+
+	for _, field := range fields[1:] {
+		key, ruleValue := getKeyValuePair(strings.TrimSpace(field))
+
+		if key == "types" {
+			for _, commitType := range strings.Split(ruleValue, ",") {
+				commitType = strings.TrimSpace(commitType)
+				if commitType != "" {
+					result.Types = append(result.Types, commitType)
+				}
+			}
+		} else if key == "max_subject" {
+			result.MaxSubjectLength, _ = strconv.Atoi(ruleValue)
+		} else if key == "blank_line_before_body" {
+			result.BlankLineBeforeBody = ruleValue == "true"
+		} else if key == "no_trailing_period" {
+			result.NoTrailingPeriod = ruleValue == "true"
+		}
+	}
+
+	return
+}
+
+func formatCommitRules(repoPath string, rules CommitRules) string {
+	var sb strings.Builder
+	sb.WriteString(repoPath)
+
+	if len(rules.Types) > 0 {
+		sb.WriteString(fmt.Sprintf(";types=%s", strings.Join(rules.Types, ",")))
+	}
+	if rules.MaxSubjectLength > 0 {
+		sb.WriteString(fmt.Sprintf(";max_subject=%d", rules.MaxSubjectLength))
+	}
+	if rules.BlankLineBeforeBody {
+		sb.WriteString(";blank_line_before_body=true")
+	}
+	if rules.NoTrailingPeriod {
+		sb.WriteString(";no_trailing_period=true")
+	}
+
+	return sb.String()
+}
+
+func parseDiffOptions(value string) (repoPath string, result DiffOptions) {
+	fields := strings.Split(value, ";")
+	repoPath = strings.TrimSpace(fields[0])
+
+	for _, field := range fields[1:] {
+		key, optionValue := getKeyValuePair(strings.TrimSpace(field))
+		enabled := optionValue == "true"
+
+		if key == "ignore_space_change" {
+			result.IgnoreSpaceChange = enabled
+		} else if key == "ignore_all_space" {
+			result.IgnoreAllSpace = enabled
+		} else if key == "ignore_blank_lines" {
+			result.IgnoreBlankLines = enabled
+		} else if key == "ignore_cr_at_eol" {
+			result.IgnoreCRAtEOL = enabled
+		} else if key == "show_whitespace" {
+			result.ShowWhitespace = enabled
+		}
+	}
+
+	return
+}
+
+func formatDiffOptions(repoPath string, options DiffOptions) string {
+	var sb strings.Builder
+	sb.WriteString(repoPath)
+
+	if options.IgnoreSpaceChange {
+		sb.WriteString(";ignore_space_change=true")
+	}
+	if options.IgnoreAllSpace {
+		sb.WriteString(";ignore_all_space=true")
+	}
+	if options.IgnoreBlankLines {
+		sb.WriteString(";ignore_blank_lines=true")
+	}
+	if options.IgnoreCRAtEOL {
+		sb.WriteString(";ignore_cr_at_eol=true")
+	}
+	if options.ShowWhitespace {
+		sb.WriteString(";show_whitespace=true")
+	}
+
+	return sb.String()
+}
+
 func getSettingsPath() string {
 	cacheDir, _ := os.UserCacheDir()
 	return fmt.Sprintf("%s/gitgud.conf", cacheDir)
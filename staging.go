@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/DonutLaser/git-client/git"
 	"github.com/DonutLaser/git-client/renderer"
 	"github.com/veandco/go-sdl2/sdl"
@@ -9,15 +11,24 @@ import (
 type Staging struct {
 	Rect *sdl.Rect
 
-	Entries     []git.GitStatusEntry
-	ActiveEntry int
-	AllSelected bool
+	// Conflicted entries come first, followed by the staged and then the unstaged ones
+	Entries       []git.GitStatusEntry
+	ConflictCount int
+	StagedCount   int
+	ActiveEntry   int
+
+	UnstagedTitle string
+
+	// Files marked for an action on several of them at once, e.g. stashing
+	Marked map[string]bool
 }
 
 func NewStaging(windowHeight int32) (result Staging) {
 	result.Rect = &sdl.Rect{X: 0, Y: 24 + 2, W: 280, H: windowHeight - 24 - 2}
 
 	result.ActiveEntry = -1
+	result.UnstagedTitle = "Changes"
+	result.Marked = make(map[string]bool)
 
 	return
 }
@@ -27,11 +38,46 @@ func (staging *Staging) Resize(windowHeight int32) {
 }
 
 func (staging *Staging) ShowEntries(entries []git.GitStatusEntry) {
-	staging.Entries = entries
-	if len(staging.Entries) > 0 {
+	var previous git.GitStatusEntry
+	hadPrevious := staging.ActiveEntry >= 0 && staging.ActiveEntry < len(staging.Entries)
+	if hadPrevious {
+		previous = staging.Entries[staging.ActiveEntry]
+	}
+
+	conflicted, rest := git.SplitConflictedEntries(entries)
+	staged, unstaged := git.SplitStagedEntries(rest)
+
+	staging.Entries = append(append(conflicted, staged...), unstaged...)
+	staging.ConflictCount = len(conflicted)
+	staging.StagedCount = len(staged)
+
+	for filename := range staging.Marked {
+		if !staging.hasFile(filename) {
+			delete(staging.Marked, filename)
+		}
+	}
+
+	// Keep the cursor on the same entry, or at least where it was, so that staging entries
+	// one by one doesn't jump back to the top
+	if hadPrevious {
+		for index, entry := range staging.Entries {
+			if entry.Filename == previous.Filename && entry.Staged == previous.Staged {
+				staging.ActiveEntry = index
+				return
+			}
+		}
+	}
+
+	if staging.ActiveEntry >= len(staging.Entries) {
+		staging.ActiveEntry = len(staging.Entries) - 1
+	}
+	if staging.ActiveEntry < 0 && len(staging.Entries) > 0 {
 		staging.ActiveEntry = 0
 	}
-	staging.AllSelected = true
+}
+
+func (staging *Staging) ResetActiveEntry() {
+	staging.ActiveEntry = 0
 }
 
 func (staging *Staging) GoToNextEntry() {
@@ -48,16 +94,54 @@ func (staging *Staging) GoToPrevEntry() {
 	}
 }
 
-func (staging *Staging) ToggleEntrySelected() {
-	staging.Entries[staging.ActiveEntry].Selected = !staging.Entries[staging.ActiveEntry].Selected
+func (staging *Staging) HasUnstagedEntries() bool {
+	return len(staging.Entries) > staging.ConflictCount+staging.StagedCount
 }
 
-func (staging *Staging) ToggleAllEntriesSelected() {
-	staging.AllSelected = !staging.AllSelected
+func (staging *Staging) HasConflictedEntries() bool {
+	return staging.ConflictCount > 0
+}
 
-	for index := range staging.Entries {
-		staging.Entries[index].Selected = staging.AllSelected
+func (staging *Staging) ToggleMark() {
+	if staging.ActiveEntry < 0 || staging.ActiveEntry >= len(staging.Entries) {
+		return
 	}
+
+	filename := staging.GetActiveEntryFileName()
+	if staging.Marked[filename] {
+		delete(staging.Marked, filename)
+	} else {
+		staging.Marked[filename] = true
+	}
+}
+
+func (staging *Staging) ClearMarks() {
+	staging.Marked = make(map[string]bool)
+}
+
+// Returns the marked files, or the active one if nothing is marked
+func (staging *Staging) GetMarkedFileNames() (result []string) {
+	for _, entry := range staging.Entries {
+		if staging.Marked[entry.Filename] && !containsString(result, entry.Filename) {
+			result = append(result, entry.Filename)
+		}
+	}
+
+	if len(result) == 0 && staging.ActiveEntry >= 0 && staging.ActiveEntry < len(staging.Entries) {
+		result = append(result, staging.GetActiveEntryFileName())
+	}
+
+	return
+}
+
+func (staging *Staging) hasFile(filename string) bool {
+	for _, entry := range staging.Entries {
+		if entry.Filename == filename {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (staging *Staging) DiscardActiveEntry() {
@@ -88,6 +172,18 @@ func (staging *Staging) Render(rend *sdl.Renderer, app *App) {
 	var entryHeight int32 = 28
 
 	for index, entry := range staging.Entries {
+		unstagedStart := staging.ConflictCount + staging.StagedCount
+
+		if index == 0 && staging.ConflictCount > 0 {
+			top = staging.renderSectionHeader(rend, app, fmt.Sprintf("Conflicts (%d)", staging.ConflictCount), top)
+		}
+		if index == staging.ConflictCount && staging.StagedCount > 0 {
+			top = staging.renderSectionHeader(rend, app, fmt.Sprintf("Staged changes (%d)", staging.StagedCount), top)
+		}
+		if index == unstagedStart {
+			top = staging.renderSectionHeader(rend, app, fmt.Sprintf("%s (%d)", staging.UnstagedTitle, len(staging.Entries)-unstagedStart), top)
+		}
+
 		bgRect := sdl.Rect{
 			X: staging.Rect.X,
 			Y: top,
@@ -104,8 +200,17 @@ func (staging *Staging) Render(rend *sdl.Renderer, app *App) {
 		if index == staging.ActiveEntry {
 			renderer.DrawRectOutline(rend, &bgRect, sdl.Color{R: 92, G: 91, B: 92, A: 255}, 1)
 		}
+		if staging.Marked[entry.Filename] {
+			markRect := sdl.Rect{X: bgRect.X, Y: bgRect.Y, W: 4, H: bgRect.H}
+			renderer.DrawRect(rend, &markRect, sdl.Color{R: 38, G: 139, B: 210, A: 255})
+		}
+
+		name := entry.Filename
+		if entry.OrigFilename != "" {
+			name = fmt.Sprintf("%s -> %s", entry.OrigFilename, entry.Filename)
+		}
 
-		nameWidth := mainFont.GetStringWidth(entry.Filename)
+		nameWidth := mainFont.GetStringWidth(name)
 		nameRect := sdl.Rect{
 			X: bgRect.X + 10,
 			Y: bgRect.Y + (bgRect.H-mainFont.Size)/2,
@@ -113,15 +218,10 @@ func (staging *Staging) Render(rend *sdl.Renderer, app *App) {
 			H: mainFont.Size,
 		}
 
-		nameColor := sdl.Color{R: 171, G: 171, B: 171, A: 255}
-		if !entry.Selected {
-			nameColor = sdl.Color{R: 93, G: 93, B: 93, A: 255}
-		}
-
-		renderer.DrawText(rend, &mainFont, entry.Filename, &nameRect, nameColor)
+		renderer.DrawText(rend, &mainFont, name, &nameRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
 
 		icon := onIcon
-		if !entry.Selected {
+		if !entry.Staged {
 			icon = offIcon
 		}
 
@@ -132,6 +232,22 @@ func (staging *Staging) Render(rend *sdl.Renderer, app *App) {
 	}
 }
 
+func (staging *Staging) renderSectionHeader(rend *sdl.Renderer, app *App, title string, top int32) int32 {
+	headerFont := app.Fonts["12"]
+
+	var headerHeight int32 = 22
+
+	titleRect := sdl.Rect{
+		X: staging.Rect.X + 10,
+		Y: top + (headerHeight-headerFont.Size)/2,
+		W: headerFont.GetStringWidth(title),
+		H: headerFont.Size,
+	}
+	renderer.DrawText(rend, &headerFont, title, &titleRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+	return top + headerHeight
+}
+
 func (staging *Staging) changeTypeToColor(t git.GitStatusEntryType) sdl.Color {
 	switch t {
 	case git.GIT_ENTRY_MODIFIED:
@@ -142,7 +258,23 @@ func (staging *Staging) changeTypeToColor(t git.GitStatusEntryType) sdl.Color {
 		return sdl.Color{R: 82, G: 153, B: 19, A: 255}
 	case git.GIT_ENTRY_DELETED:
 		return sdl.Color{R: 169, G: 26, B: 23, A: 255}
+	case git.GIT_ENTRY_RENAMED:
+		fallthrough
+	case git.GIT_ENTRY_COPIED:
+		return sdl.Color{R: 38, G: 139, B: 210, A: 255}
+	case git.GIT_ENTRY_CONFLICTED:
+		return sdl.Color{R: 211, G: 54, B: 130, A: 255}
 	default:
-		panic("Unreachable")
+		return sdl.Color{R: 171, G: 171, B: 171, A: 255}
 	}
 }
+
+func containsString(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+
+	return false
+}
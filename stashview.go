@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type StashView struct {
+	Rect *sdl.Rect
+
+	Entries      []git.GitStashEntry
+	ActiveEntry  int
+	FirstVisible int
+}
+
+func NewStashView(windowWidth int32, windowHeight int32) (result StashView) {
+	result.Rect = &sdl.Rect{X: 0, Y: 24 + 2, W: windowWidth, H: windowHeight - 24 - 2}
+
+	return
+}
+
+func (view *StashView) Resize(windowWidth int32, windowHeight int32) {
+	view.Rect.W = windowWidth
+	view.Rect.H = windowHeight - 24 - 2
+
+	view.scrollToActiveEntry()
+}
+
+func (view *StashView) ShowEntries(entries []git.GitStashEntry) {
+	view.Entries = entries
+
+	if view.ActiveEntry >= len(view.Entries) {
+		view.ActiveEntry = len(view.Entries) - 1
+	}
+	if view.ActiveEntry < 0 {
+		view.ActiveEntry = 0
+	}
+
+	view.scrollToActiveEntry()
+}
+
+func (view *StashView) HasEntries() bool {
+	return len(view.Entries) > 0
+}
+
+func (view *StashView) GetActiveEntry() git.GitStashEntry {
+	return view.Entries[view.ActiveEntry]
+}
+
+func (view *StashView) GoToNextEntry() {
+	if view.ActiveEntry < len(view.Entries)-1 {
+		view.ActiveEntry += 1
+	}
+
+	view.scrollToActiveEntry()
+}
+
+func (view *StashView) GoToPrevEntry() {
+	if view.ActiveEntry > 0 {
+		view.ActiveEntry -= 1
+	}
+
+	view.scrollToActiveEntry()
+}
+
+func (view *StashView) visibleRows() int {
+	return int(view.Rect.H / 24)
+}
+
+func (view *StashView) scrollToActiveEntry() {
+	if view.ActiveEntry < view.FirstVisible {
+		view.FirstVisible = view.ActiveEntry
+	} else if view.ActiveEntry >= view.FirstVisible+view.visibleRows() {
+		view.FirstVisible = view.ActiveEntry - view.visibleRows() + 1
+	}
+}
+
+func (view *StashView) Render(rend *sdl.Renderer, app *App) {
+	renderer.DrawRect(rend, view.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+
+	mainFont := app.Fonts["12"]
+
+	if len(view.Entries) == 0 {
+		text := "No stashed changes, press s to stash everything"
+		textWidth := mainFont.GetStringWidth(text)
+		textRect := sdl.Rect{
+			X: view.Rect.X + (view.Rect.W-textWidth)/2,
+			Y: view.Rect.Y + (view.Rect.H-mainFont.Size)/2,
+			W: textWidth,
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, text, &textRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+
+		return
+	}
+
+	renderer.ClipRect(rend, view.Rect)
+
+	var rowHeight int32 = 24
+
+	top := view.Rect.Y
+	for index := view.FirstVisible; index < len(view.Entries) && top < view.Rect.Y+view.Rect.H; index += 1 {
+		entry := view.Entries[index]
+
+		rowRect := sdl.Rect{X: view.Rect.X, Y: top, W: view.Rect.W, H: rowHeight}
+		if index == view.ActiveEntry {
+			renderer.DrawRect(rend, &rowRect, sdl.Color{R: 77, G: 77, B: 77, A: 255})
+			renderer.DrawRectOutline(rend, &rowRect, sdl.Color{R: 92, G: 91, B: 92, A: 255}, 1)
+		}
+
+		textTop := top + (rowHeight-mainFont.Size)/2
+		left := view.Rect.X + 10
+
+		indexRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(entry.Index), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, entry.Index, &indexRect, sdl.Color{R: 207, G: 173, B: 16, A: 255})
+		left += mainFont.CharacterWidth*10 + 10
+
+		branch := fmt.Sprintf("[%s]", entry.BranchName)
+		branchRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(branch), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, branch, &branchRect, sdl.Color{R: 38, G: 139, B: 210, A: 255})
+		left += branchRect.W + 10
+
+		date := relativeTime(entry.Date)
+		dateLeft := view.Rect.X + view.Rect.W - 10 - mainFont.GetStringWidth(date)
+
+		maxMessageChars := int((dateLeft - 20 - left) / mainFont.CharacterWidth)
+		if maxMessageChars < 0 {
+			maxMessageChars = 0
+		}
+
+		message := truncateText(entry.Message, maxMessageChars)
+		messageRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(message), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, message, &messageRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+
+		dateRect := sdl.Rect{X: dateLeft, Y: textTop, W: mainFont.GetStringWidth(date), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, date, &dateRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		top += rowHeight
+	}
+
+	renderer.ClipRect(rend, nil)
+}
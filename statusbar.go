@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+
+	"github.com/DonutLaser/git-client/git"
 	"github.com/DonutLaser/git-client/renderer"
 	"github.com/veandco/go-sdl2/sdl"
 )
@@ -10,7 +13,12 @@ type Statusbar struct {
 
 	RepoName    string
 	BranchName  string
+	Detached    bool
 	StashExists bool
+	RemoteText  string
+
+	// Shown while a merge or rebase waits to be continued or aborted
+	OperationText string
 
 	StashExistsText string
 }
@@ -35,12 +43,47 @@ func (statusbar *Statusbar) ShowRepoName(name string) {
 
 func (statusbar *Statusbar) ShowBranchName(name string) {
 	statusbar.BranchName = name
+	statusbar.Detached = false
+}
+
+func (statusbar *Statusbar) ShowDetachedHead(name string) {
+	statusbar.BranchName = "HEAD detached at " + name
+	statusbar.Detached = true
 }
 
 func (statusbar *Statusbar) ShowStashExists(exists bool) {
 	statusbar.StashExists = exists
 }
 
+func (statusbar *Statusbar) ShowAheadBehind(upstream string, ahead int, behind int) {
+	if upstream == "" {
+		statusbar.RemoteText = "no upstream"
+	} else {
+		statusbar.RemoteText = fmt.Sprintf("%s: %s", upstream, aheadBehindText(ahead, behind))
+	}
+}
+
+func (statusbar *Statusbar) ShowOperation(state git.GitOperationState) {
+	switch state.Operation {
+	case git.GIT_OPERATION_MERGE:
+		statusbar.OperationText = "MERGING"
+	case git.GIT_OPERATION_REBASE:
+		statusbar.OperationText = "REBASING"
+		if state.HeadName != "" {
+			statusbar.OperationText += " " + state.HeadName
+		}
+		if state.Total > 0 {
+			statusbar.OperationText += fmt.Sprintf(" (%d/%d)", state.Step, state.Total)
+		}
+	case git.GIT_OPERATION_CHERRY_PICK:
+		statusbar.OperationText = "CHERRY-PICKING " + shortHash(state.StoppedAt)
+	case git.GIT_OPERATION_REVERT:
+		statusbar.OperationText = "REVERTING " + shortHash(state.StoppedAt)
+	default:
+		statusbar.OperationText = ""
+	}
+}
+
 func (statusbar *Statusbar) Render(rend *sdl.Renderer, app *App) {
 	renderer.DrawRect(rend, statusbar.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
 
@@ -67,7 +110,13 @@ func (statusbar *Statusbar) Render(rend *sdl.Renderer, app *App) {
 		renderer.DrawText(rend, &mainFont, statusbar.StashExistsText, &stashRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
 	}
 
-	totalWidth := (repoIcon.Width + 5 + repoNameWidth) + 20 + (branchIcon.Width + 5 + branchNameWidth)
+	remoteTextWidth := mainFont.GetStringWidth(statusbar.RemoteText)
+	operationTextWidth := mainFont.GetStringWidth(statusbar.OperationText)
+
+	totalWidth := (repoIcon.Width + 5 + repoNameWidth) + 20 + (branchIcon.Width + 5 + branchNameWidth) + 20 + remoteTextWidth
+	if statusbar.OperationText != "" {
+		totalWidth += 20 + operationTextWidth
+	}
 	left := statusbar.Rect.X + (statusbar.Rect.W-totalWidth)/2
 
 	{
@@ -99,9 +148,46 @@ func (statusbar *Statusbar) Render(rend *sdl.Renderer, app *App) {
 			W: branchNameWidth,
 			H: mainFont.Size,
 		}
-		renderer.DrawText(rend, &mainFont, statusbar.BranchName, &branchNameRect, sdl.Color{R: 171, G: 171, B: 171, A: 255})
+		branchNameColor := sdl.Color{R: 171, G: 171, B: 171, A: 255}
+		if statusbar.Detached {
+			branchNameColor = sdl.Color{R: 203, G: 75, B: 22, A: 255}
+		}
+
+		renderer.DrawText(rend, &mainFont, statusbar.BranchName, &branchNameRect, branchNameColor)
 
 		left += branchNameRect.W + 20
 	}
 
+	{
+		remoteRect := sdl.Rect{
+			X: left,
+			Y: statusbar.Rect.Y + (statusbar.Rect.H-mainFont.Size)/2 + 1,
+			W: remoteTextWidth,
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, statusbar.RemoteText, &remoteRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		left += remoteRect.W + 20
+	}
+
+	if statusbar.OperationText != "" {
+		operationRect := sdl.Rect{
+			X: left,
+			Y: statusbar.Rect.Y + (statusbar.Rect.H-mainFont.Size)/2 + 1,
+			W: operationTextWidth,
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, statusbar.OperationText, &operationRect, sdl.Color{R: 203, G: 75, B: 22, A: 255})
+
+		left += operationRect.W + 20
+	}
+
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+
+	return hash
 }
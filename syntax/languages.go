@@ -0,0 +1,134 @@
+package syntax
+
+var cStrings = []Delimiters{
+	{Open: "\"", Close: "\"", Escapes: true},
+	{Open: "'", Close: "'", Escapes: true},
+}
+
+var cBlockComments = []Delimiters{{Open: "/*", Close: "*/"}}
+
+var languages = []Language{
+	{
+		Name:       "Go",
+		Extensions: []string{".go"},
+		Keywords: []string{
+			"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func", "go",
+			"goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct", "switch", "type", "var",
+		},
+		Types: []string{
+			"any", "bool", "byte", "complex64", "complex128", "error", "float32", "float64", "int", "int8", "int16", "int32",
+			"int64", "rune", "string", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		},
+		Constants:        []string{"true", "false", "nil", "iota"},
+		LineComments:     []string{"//"},
+		BlockComments:    cBlockComments,
+		Strings:          cStrings,
+		MultiLineStrings: []Delimiters{{Open: "`", Close: "`"}},
+	},
+	{
+		Name:       "C",
+		Extensions: []string{".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp"},
+		Keywords: []string{
+			"auto", "break", "case", "class", "const", "constexpr", "continue", "default", "delete", "do", "else", "enum",
+			"extern", "for", "goto", "if", "inline", "namespace", "new", "operator", "private", "protected", "public",
+			"register", "return", "sizeof", "static", "struct", "switch", "template", "this", "typedef", "typename", "union",
+			"using", "virtual", "volatile", "while",
+		},
+		Types: []string{
+			"bool", "char", "double", "float", "int", "long", "short", "signed", "unsigned", "void", "size_t", "int8_t",
+			"int16_t", "int32_t", "int64_t", "uint8_t", "uint16_t", "uint32_t", "uint64_t",
+		},
+		Constants:     []string{"true", "false", "NULL", "nullptr"},
+		LineComments:  []string{"//"},
+		BlockComments: cBlockComments,
+		Strings:       cStrings,
+		LinePrefixes:  []LinePrefix{{Prefix: "#", Type: TOKEN_KEYWORD}},
+	},
+	{
+		Name:       "JavaScript",
+		Extensions: []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts"},
+		Keywords: []string{
+			"abstract", "as", "async", "await", "break", "case", "catch", "class", "const", "continue", "debugger", "declare",
+			"default", "delete", "do", "else", "enum", "export", "extends", "finally", "for", "from", "function", "if",
+			"implements", "import", "in", "instanceof", "interface", "let", "new", "of", "private", "protected", "public",
+			"readonly", "return", "static", "super", "switch", "this", "throw", "try", "type", "typeof", "var", "void",
+			"while", "yield",
+		},
+		Types:            []string{"any", "boolean", "never", "number", "object", "string", "symbol", "unknown", "bigint"},
+		Constants:        []string{"true", "false", "null", "undefined", "NaN", "Infinity"},
+		LineComments:     []string{"//"},
+		BlockComments:    cBlockComments,
+		Strings:          cStrings,
+		MultiLineStrings: []Delimiters{{Open: "`", Close: "`", Escapes: true}},
+		IdentifierChars:  "$",
+	},
+	{
+		Name:       "Python",
+		Extensions: []string{".py", ".pyw", ".pyi"},
+		Keywords: []string{
+			"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif", "else", "except",
+			"finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal", "not", "or", "pass",
+			"raise", "return", "try", "while", "with", "yield",
+		},
+		Types:        []string{"bool", "bytes", "dict", "float", "int", "list", "object", "set", "str", "tuple"},
+		Constants:    []string{"True", "False", "None", "self"},
+		LineComments: []string{"#"},
+		Strings:      cStrings,
+		MultiLineStrings: []Delimiters{
+			{Open: "\"\"\"", Close: "\"\"\"", Escapes: true},
+			{Open: "'''", Close: "'''", Escapes: true},
+		},
+	},
+	{
+		Name:       "Rust",
+		Extensions: []string{".rs"},
+		Keywords: []string{
+			"as", "async", "await", "break", "const", "continue", "crate", "dyn", "else", "enum", "extern", "fn", "for",
+			"if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref", "return", "static", "struct",
+			"super", "trait", "type", "unsafe", "use", "where", "while",
+		},
+		Types: []string{
+			"bool", "char", "f32", "f64", "i8", "i16", "i32", "i64", "i128", "isize", "str", "u8", "u16", "u32", "u64",
+			"u128", "usize", "String", "Vec", "Option", "Result", "Box", "Self",
+		},
+		Constants:     []string{"true", "false", "self", "None", "Some", "Ok", "Err"},
+		LineComments:  []string{"//"},
+		BlockComments: cBlockComments,
+		// Single quotes are left out because lifetimes like 'a don't have a closing one
+		MultiLineStrings: []Delimiters{
+			{Open: "r#\"", Close: "\"#"},
+			{Open: "r\"", Close: "\""},
+			{Open: "\"", Close: "\"", Escapes: true},
+		},
+	},
+	{
+		Name:       "JSON",
+		Extensions: []string{".json", ".jsonc"},
+		Constants:  []string{"true", "false", "null"},
+		// Comments are not part of JSON, but lots of config files have them anyway
+		LineComments:  []string{"//"},
+		BlockComments: cBlockComments,
+		Strings:       []Delimiters{{Open: "\"", Close: "\"", Escapes: true}},
+		Keys:          true,
+	},
+	{
+		Name:            "YAML",
+		Extensions:      []string{".yaml", ".yml"},
+		Constants:       []string{"true", "false", "null", "yes", "no", "on", "off"},
+		LineComments:    []string{"#"},
+		Strings:         cStrings,
+		LinePrefixes:    []LinePrefix{{Prefix: "---", Type: TOKEN_KEYWORD}, {Prefix: "...", Type: TOKEN_KEYWORD}},
+		IdentifierChars: "-./",
+		Keys:            true,
+	},
+	{
+		Name:       "Markdown",
+		Extensions: []string{".md", ".markdown"},
+		// Code blocks are treated like strings, the code in them is not highlighted
+		MultiLineStrings: []Delimiters{{Open: "```", Close: "```"}},
+		BlockComments:    []Delimiters{{Open: "<!--", Close: "-->"}},
+		Strings:          []Delimiters{{Open: "`", Close: "`"}},
+		LinePrefixes:     []LinePrefix{{Prefix: "#", Type: TOKEN_HEADING}, {Prefix: ">", Type: TOKEN_COMMENT}},
+		NoIdentifiers:    true,
+	},
+}
@@ -0,0 +1,272 @@
+package syntax
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenType uint8
+
+const (
+	TOKEN_TEXT TokenType = iota
+	TOKEN_KEYWORD
+	TOKEN_TYPE
+	TOKEN_CONSTANT
+	TOKEN_NUMBER
+	TOKEN_STRING
+	TOKEN_COMMENT
+	TOKEN_FUNCTION
+	TOKEN_KEY
+	TOKEN_HEADING
+)
+
+// Byte offsets [Start, End) into a line. Text between tokens is TOKEN_TEXT.
+type Token struct {
+	Start int
+	End   int
+	Type  TokenType
+}
+
+type Delimiters struct {
+	Open  string
+	Close string
+
+	// Whether a backslash keeps the next character from closing the string
+	Escapes bool
+}
+
+// A line that starts with Prefix, ignoring indentation, is a single token of Type
+type LinePrefix struct {
+	Prefix string
+	Type   TokenType
+}
+
+type Language struct {
+	Name       string
+	Extensions []string
+
+	Keywords  []string
+	Types     []string
+	Constants []string
+
+	LineComments  []string
+	BlockComments []Delimiters
+	// Strings end at the end of the line even if they are not closed, multi-line strings don't
+	Strings          []Delimiters
+	MultiLineStrings []Delimiters
+	LinePrefixes     []LinePrefix
+
+	// Characters besides letters, digits and underscores that identifiers can contain
+	IdentifierChars string
+	// Strings and identifiers followed by a colon are keys, e.g. in JSON and YAML
+	Keys bool
+	// Words are only text, e.g. in Markdown
+	NoIdentifiers bool
+}
+
+// Where the previous line left off, only multi-line constructs carry over to the next line
+type State struct {
+	// The delimiter that ends the construct the line starts in, "" if it starts outside of one
+	Close   string
+	Type    TokenType
+	Escapes bool
+}
+
+// Returns the language of the file based on its extension, or nil if it isn't known
+func ForFile(filename string) *Language {
+	extension := strings.ToLower(filepath.Ext(filename))
+	if extension == "" {
+		return nil
+	}
+
+	for index := range languages {
+		for _, languageExtension := range languages[index].Extensions {
+			if languageExtension == extension {
+				return &languages[index]
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the state at the start of every line of source, so that a line from the middle of the
+// file can be tokenized without the lines above it
+func (lang *Language) LineStates(source string) (result []State) {
+	state := State{}
+	for _, line := range strings.Split(source, "\n") {
+		result = append(result, state)
+		_, state = lang.TokenizeLine(strings.TrimSuffix(line, "\r"), state)
+	}
+
+	return
+}
+
+func (lang *Language) TokenizeLine(line string, state State) (result []Token, next State) {
+	pos := 0
+
+	if state.Close != "" {
+		end, closed := findClose(line, 0, state.Close, state.Escapes)
+		result = appendToken(result, 0, end, state.Type)
+		if !closed {
+			return result, state
+		}
+
+		pos = end
+	} else {
+		trimmed := strings.TrimLeft(line, " \t")
+		for _, prefix := range lang.LinePrefixes {
+			if strings.HasPrefix(trimmed, prefix.Prefix) {
+				return appendToken(result, len(line)-len(trimmed), len(line), prefix.Type), State{}
+			}
+		}
+	}
+
+	for pos < len(line) {
+		rest := line[pos:]
+
+		if delimiters, found := matchDelimiters(rest, lang.BlockComments); found {
+			end, closed := findClose(line, pos+len(delimiters.Open), delimiters.Close, false)
+			result = appendToken(result, pos, end, TOKEN_COMMENT)
+			if !closed {
+				return result, State{Close: delimiters.Close, Type: TOKEN_COMMENT}
+			}
+
+			pos = end
+		} else if matchPrefix(rest, lang.LineComments) {
+			result = appendToken(result, pos, len(line), TOKEN_COMMENT)
+			pos = len(line)
+		} else if delimiters, found := matchDelimiters(rest, lang.MultiLineStrings); found {
+			end, closed := findClose(line, pos+len(delimiters.Open), delimiters.Close, delimiters.Escapes)
+			result = appendToken(result, pos, end, TOKEN_STRING)
+			if !closed {
+				return result, State{Close: delimiters.Close, Type: TOKEN_STRING, Escapes: delimiters.Escapes}
+			}
+
+			pos = end
+		} else if delimiters, found := matchDelimiters(rest, lang.Strings); found {
+			end, _ := findClose(line, pos+len(delimiters.Open), delimiters.Close, delimiters.Escapes)
+
+			tokenType := TOKEN_STRING
+			if lang.Keys && isFollowedByColon(line, end) {
+				tokenType = TOKEN_KEY
+			}
+
+			result = appendToken(result, pos, end, tokenType)
+			pos = end
+		} else {
+			r, size := utf8.DecodeRuneInString(rest)
+
+			if lang.NoIdentifiers {
+				pos += size
+			} else if unicode.IsDigit(r) {
+				end := pos + scanWhile(rest, isNumberRune)
+				result = appendToken(result, pos, end, TOKEN_NUMBER)
+				pos = end
+			} else if lang.isIdentifierRune(r) {
+				end := pos + scanWhile(rest, lang.isIdentifierRune)
+				result = appendToken(result, pos, end, lang.identifierType(line, line[pos:end], end))
+				pos = end
+			} else {
+				pos += size
+			}
+		}
+	}
+
+	return result, State{}
+}
+
+func (lang *Language) identifierType(line string, word string, end int) TokenType {
+	if lang.Keys && isFollowedByColon(line, end) {
+		return TOKEN_KEY
+	} else if containsWord(lang.Keywords, word) {
+		return TOKEN_KEYWORD
+	} else if containsWord(lang.Types, word) {
+		return TOKEN_TYPE
+	} else if containsWord(lang.Constants, word) {
+		return TOKEN_CONSTANT
+	} else if end < len(line) && line[end] == '(' {
+		return TOKEN_FUNCTION
+	}
+
+	return TOKEN_TEXT
+}
+
+func (lang *Language) isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(lang.IdentifierChars, r)
+}
+
+// Numbers include hex digits, exponents, separators and suffixes like 0x1F, 1.5e10, 1_000 or 10u8
+func isNumberRune(r rune) bool {
+	return r == '.' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Returns the end of the construct, which is the end of the line if it isn't closed on it
+func findClose(line string, from int, close string, escapes bool) (int, bool) {
+	for pos := from; pos < len(line); pos += 1 {
+		if escapes && line[pos] == '\\' {
+			pos += 1
+		} else if strings.HasPrefix(line[pos:], close) {
+			return pos + len(close), true
+		}
+	}
+
+	return len(line), false
+}
+
+func matchDelimiters(text string, delimiters []Delimiters) (Delimiters, bool) {
+	for _, candidate := range delimiters {
+		if strings.HasPrefix(text, candidate.Open) {
+			return candidate, true
+		}
+	}
+
+	return Delimiters{}, false
+}
+
+func matchPrefix(text string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isFollowedByColon(line string, end int) bool {
+	return strings.HasPrefix(strings.TrimLeft(line[end:], " \t"), ":")
+}
+
+func scanWhile(text string, accept func(rune) bool) (result int) {
+	for result < len(text) {
+		r, size := utf8.DecodeRuneInString(text[result:])
+		if !accept(r) {
+			break
+		}
+
+		result += size
+	}
+
+	return
+}
+
+func containsWord(words []string, word string) bool {
+	for _, candidate := range words {
+		if candidate == word {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendToken(tokens []Token, start int, end int, tokenType TokenType) []Token {
+	if start >= end || tokenType == TOKEN_TEXT {
+		return tokens
+	}
+
+	return append(tokens, Token{Start: start, End: end, Type: tokenType})
+}
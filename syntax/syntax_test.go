@@ -0,0 +1,195 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+var tokenTypeNames = map[TokenType]string{
+	TOKEN_KEYWORD:  "keyword",
+	TOKEN_TYPE:     "type",
+	TOKEN_CONSTANT: "constant",
+	TOKEN_NUMBER:   "number",
+	TOKEN_STRING:   "string",
+	TOKEN_COMMENT:  "comment",
+	TOKEN_FUNCTION: "function",
+	TOKEN_KEY:      "key",
+	TOKEN_HEADING:  "heading",
+}
+
+// Tokens as "type text", which is easier to read in a test than offsets
+func describeTokens(line string, tokens []Token) (result []string) {
+	for _, token := range tokens {
+		result = append(result, tokenTypeNames[token.Type]+" "+line[token.Start:token.End])
+	}
+
+	return
+}
+
+func expectTokens(t *testing.T, what string, line string, tokens []Token, want ...string) {
+	t.Helper()
+
+	got := describeTokens(line, tokens)
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("%s: got %q, want %q", what, got, want)
+	}
+}
+
+func languageForTest(t *testing.T, filename string) *Language {
+	t.Helper()
+
+	lang := ForFile(filename)
+	if lang == nil {
+		t.Fatalf("no language for %s", filename)
+	}
+
+	return lang
+}
+
+func TestForFile(t *testing.T) {
+	tests := map[string]string{
+		"main.go":       "Go",
+		"include/a.HPP": "C",
+		"app.tsx":       "JavaScript",
+		"setup.py":      "Python",
+		"lib.rs":        "Rust",
+		"package.json":  "JSON",
+		"ci.yml":        "YAML",
+		"README.md":     "Markdown",
+	}
+
+	for filename, want := range tests {
+		if lang := ForFile(filename); lang == nil || lang.Name != want {
+			t.Errorf("%s: got %v, want %s", filename, lang, want)
+		}
+	}
+
+	for _, filename := range []string{"Makefile", "notes.txt", ".gitignore"} {
+		if lang := ForFile(filename); lang != nil {
+			t.Errorf("%s: got %s, want none", filename, lang.Name)
+		}
+	}
+}
+
+func TestKeywordsAndComments(t *testing.T) {
+	tests := []struct {
+		filename string
+		line     string
+		want     []string
+	}{
+		{"a.go", "func f() int { return nil } // done", []string{"keyword func", "function f", "type int", "keyword return", "constant nil", "comment // done"}},
+		{"a.go", "x := 0x1F + 1_000 /* inline */ + y", []string{"number 0x1F", "number 1_000", "comment /* inline */"}},
+		{"a.c", "  #include <stdio.h>", []string{"keyword #include <stdio.h>"}},
+		{"a.c", "static int x = NULL; /* c */", []string{"keyword static", "type int", "constant NULL", "comment /* c */"}},
+		{"a.ts", "const $el: number = null; // c", []string{"keyword const", "type number", "constant null", "comment // c"}},
+		{"a.py", "def f(self): return None  # c", []string{"keyword def", "function f", "constant self", "keyword return", "constant None", "comment # c"}},
+		{"a.rs", "pub fn main() -> Option<u8> { 'a } // c", []string{"keyword pub", "keyword fn", "function main", "type Option", "type u8", "comment // c"}},
+		{"a.json", "{\"key\": true, \"value\": \"text\", \"n\": 1}", []string{"key \"key\"", "constant true", "key \"value\"", "string \"text\"", "key \"n\"", "number 1"}},
+		{"a.yml", "name: my-app # c", []string{"key name", "comment # c"}},
+		{"a.yml", "---", []string{"keyword ---"}},
+		{"a.md", "# Title", []string{"heading # Title"}},
+		{"a.md", "> quoted if", []string{"comment > quoted if"}},
+		{"a.md", "use `code` here <!-- c -->", []string{"string `code`", "comment <!-- c -->"}},
+	}
+
+	for _, test := range tests {
+		lang := languageForTest(t, test.filename)
+		tokens, next := lang.TokenizeLine(test.line, State{})
+
+		expectTokens(t, test.filename+": "+test.line, test.line, tokens, test.want...)
+		if next != (State{}) {
+			t.Errorf("%s: %s leaves state %+v", test.filename, test.line, next)
+		}
+	}
+}
+
+func TestEscapedQuotes(t *testing.T) {
+	lang := languageForTest(t, "a.go")
+
+	line := `s := "a \"b\" c" // x`
+	tokens, _ := lang.TokenizeLine(line, State{})
+	expectTokens(t, "escaped quotes", line, tokens, `string "a \"b\" c"`, "comment // x")
+
+	line = `s := "a\\" + "b"`
+	tokens, _ = lang.TokenizeLine(line, State{})
+	expectTokens(t, "escaped backslash", line, tokens, `string "a\\"`, `string "b"`)
+
+	// Raw strings have no escapes
+	line = "s := `a\\` + x"
+	tokens, _ = lang.TokenizeLine(line, State{})
+	expectTokens(t, "raw string", line, tokens, "string `a\\`")
+}
+
+func TestUnclosedStringEndsWithLine(t *testing.T) {
+	lang := languageForTest(t, "a.go")
+
+	line := `s := "open`
+	tokens, next := lang.TokenizeLine(line, State{})
+	expectTokens(t, "unclosed string", line, tokens, `string "open`)
+	if next != (State{}) {
+		t.Errorf("an unclosed single-line string carries over as %+v", next)
+	}
+}
+
+// A hunk in the middle of a file is tokenized with the state that LineStates found for its first line
+func TestBlockCommentOpenedAboveHunk(t *testing.T) {
+	lang := languageForTest(t, "a.go")
+	source := "x := 1\n/* start\nstill comment\nend */ return x\ny := 2\n"
+
+	states := lang.LineStates(source)
+	if len(states) != 6 {
+		t.Fatalf("got %d states for 6 lines", len(states))
+	}
+
+	for _, index := range []int{2, 3} {
+		if states[index].Close != "*/" || states[index].Type != TOKEN_COMMENT {
+			t.Errorf("line %d starts with state %+v, want inside the comment", index, states[index])
+		}
+	}
+	if states[4] != (State{}) {
+		t.Errorf("line after the comment starts with state %+v", states[4])
+	}
+
+	line := "still comment"
+	tokens, _ := lang.TokenizeLine(line, states[2])
+	expectTokens(t, "line inside the comment", line, tokens, "comment still comment")
+
+	line = "end */ return x"
+	tokens, _ = lang.TokenizeLine(line, states[3])
+	expectTokens(t, "line that closes the comment", line, tokens, "comment end */", "keyword return")
+}
+
+func TestMultiLineStringOpenedAboveHunk(t *testing.T) {
+	lang := languageForTest(t, "a.py")
+	source := "doc = \"\"\"first\nescaped \\\"\"\" still\nend\"\"\"  # done\nreturn\n"
+
+	states := lang.LineStates(source)
+	for _, index := range []int{1, 2} {
+		if states[index].Close != "\"\"\"" || states[index].Type != TOKEN_STRING || !states[index].Escapes {
+			t.Errorf("line %d starts with state %+v, want inside the string", index, states[index])
+		}
+	}
+	if states[3] != (State{}) {
+		t.Errorf("line after the string starts with state %+v", states[3])
+	}
+
+	line := "end\"\"\"  # done"
+	tokens, _ := lang.TokenizeLine(line, states[2])
+	expectTokens(t, "line that closes the string", line, tokens, "string end\"\"\"", "comment # done")
+}
+
+func TestMarkdownCodeBlock(t *testing.T) {
+	lang := languageForTest(t, "a.md")
+	states := lang.LineStates("```go\nfunc main() {}\n```\n# After\n")
+
+	line := "func main() {}"
+	tokens, next := lang.TokenizeLine(line, states[1])
+	expectTokens(t, "code block", line, tokens, "string func main() {}")
+	if next.Close != "```" {
+		t.Errorf("code block ends early with state %+v", next)
+	}
+
+	if states[3] != (State{}) {
+		t.Errorf("line after the code block starts with state %+v", states[3])
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"github.com/DonutLaser/git-client/git"
+	"github.com/DonutLaser/git-client/renderer"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type TagView struct {
+	Rect *sdl.Rect
+
+	Tags         []git.GitTag
+	ActiveTag    int
+	FirstVisible int
+}
+
+func NewTagView(windowWidth int32, windowHeight int32) (result TagView) {
+	result.Rect = &sdl.Rect{X: 0, Y: 24 + 2, W: windowWidth, H: windowHeight - 24 - 2}
+
+	return
+}
+
+func (view *TagView) Resize(windowWidth int32, windowHeight int32) {
+	view.Rect.W = windowWidth
+	view.Rect.H = windowHeight - 24 - 2
+
+	view.scrollToActiveTag()
+}
+
+func (view *TagView) ShowTags(tags []git.GitTag) {
+	view.Tags = tags
+
+	if view.ActiveTag >= len(view.Tags) {
+		view.ActiveTag = len(view.Tags) - 1
+	}
+	if view.ActiveTag < 0 {
+		view.ActiveTag = 0
+	}
+
+	view.scrollToActiveTag()
+}
+
+func (view *TagView) HasTags() bool {
+	return len(view.Tags) > 0
+}
+
+func (view *TagView) GetActiveTag() git.GitTag {
+	return view.Tags[view.ActiveTag]
+}
+
+func (view *TagView) GoToNextTag() {
+	if view.ActiveTag < len(view.Tags)-1 {
+		view.ActiveTag += 1
+	}
+
+	view.scrollToActiveTag()
+}
+
+func (view *TagView) GoToPrevTag() {
+	if view.ActiveTag > 0 {
+		view.ActiveTag -= 1
+	}
+
+	view.scrollToActiveTag()
+}
+
+func (view *TagView) visibleRows() int {
+	return int(view.Rect.H / 24)
+}
+
+func (view *TagView) scrollToActiveTag() {
+	if view.ActiveTag < view.FirstVisible {
+		view.FirstVisible = view.ActiveTag
+	} else if view.ActiveTag >= view.FirstVisible+view.visibleRows() {
+		view.FirstVisible = view.ActiveTag - view.visibleRows() + 1
+	}
+}
+
+func (view *TagView) Render(rend *sdl.Renderer, app *App) {
+	renderer.DrawRect(rend, view.Rect, sdl.Color{R: 47, G: 46, B: 47, A: 255})
+
+	mainFont := app.Fonts["12"]
+
+	if len(view.Tags) == 0 {
+		text := "No tags yet, press n to tag HEAD"
+		textWidth := mainFont.GetStringWidth(text)
+		textRect := sdl.Rect{
+			X: view.Rect.X + (view.Rect.W-textWidth)/2,
+			Y: view.Rect.Y + (view.Rect.H-mainFont.Size)/2,
+			W: textWidth,
+			H: mainFont.Size,
+		}
+		renderer.DrawText(rend, &mainFont, text, &textRect, sdl.Color{R: 221, G: 221, B: 221, A: 255})
+
+		return
+	}
+
+	renderer.ClipRect(rend, view.Rect)
+
+	var rowHeight int32 = 24
+
+	// Line the columns up on the longest tag name
+	nameColumnWidth := 0
+	for _, tag := range view.Tags {
+		nameColumnWidth = maxInt(nameColumnWidth, len(tag.Name))
+	}
+
+	top := view.Rect.Y
+	for index := view.FirstVisible; index < len(view.Tags) && top < view.Rect.Y+view.Rect.H; index += 1 {
+		tag := view.Tags[index]
+
+		rowRect := sdl.Rect{X: view.Rect.X, Y: top, W: view.Rect.W, H: rowHeight}
+		if index == view.ActiveTag {
+			renderer.DrawRect(rend, &rowRect, sdl.Color{R: 77, G: 77, B: 77, A: 255})
+			renderer.DrawRectOutline(rend, &rowRect, sdl.Color{R: 92, G: 91, B: 92, A: 255}, 1)
+		}
+
+		textTop := top + (rowHeight-mainFont.Size)/2
+		left := view.Rect.X + 10
+
+		nameRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(tag.Name), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, tag.Name, &nameRect, sdl.Color{R: 38, G: 139, B: 210, A: 255})
+		left += int32(nameColumnWidth)*mainFont.CharacterWidth + 20
+
+		target := shortHash(tag.Target)
+		targetRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(target), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, target, &targetRect, sdl.Color{R: 207, G: 173, B: 16, A: 255})
+		left += targetRect.W + 20
+
+		date := relativeTime(tag.Date)
+		dateLeft := view.Rect.X + view.Rect.W - 10 - mainFont.GetStringWidth(date)
+
+		maxMessageChars := int((dateLeft - 20 - left) / mainFont.CharacterWidth)
+		if maxMessageChars < 0 {
+			maxMessageChars = 0
+		}
+
+		// Lightweight tags have no message of their own, the subject is the commit's
+		messageColor := sdl.Color{R: 221, G: 221, B: 221, A: 255}
+		if !tag.Annotated {
+			messageColor = sdl.Color{R: 127, G: 127, B: 127, A: 255}
+		}
+
+		message := truncateText(tag.Message, maxMessageChars)
+		messageRect := sdl.Rect{X: left, Y: textTop, W: mainFont.GetStringWidth(message), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, message, &messageRect, messageColor)
+
+		dateRect := sdl.Rect{X: dateLeft, Y: textTop, W: mainFont.GetStringWidth(date), H: mainFont.Size}
+		renderer.DrawText(rend, &mainFont, date, &dateRect, sdl.Color{R: 127, G: 127, B: 127, A: 255})
+
+		top += rowHeight
+	}
+
+	renderer.ClipRect(rend, nil)
+}